@@ -56,13 +56,16 @@ CREATE TABLE IF NOT EXISTS tool_usages (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- API 密钥表
+-- API 密钥表（key 列保存明文密钥的 SHA-256，scopes 为逗号分隔的权限范围）
 CREATE TABLE IF NOT EXISTS api_keys (
     id SERIAL PRIMARY KEY,
     key VARCHAR(255) UNIQUE NOT NULL,
+    prefix VARCHAR(20),
     name VARCHAR(255),
     description TEXT,
+    scopes VARCHAR(255),
     is_active BOOLEAN DEFAULT true,
+    last_used_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     deleted_at TIMESTAMP
@@ -17,18 +17,33 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/fvbock/endless"
+	"github.com/webbleen/go-gin/models/database"
 	"github.com/webbleen/go-gin/pkg/setting"
 	"github.com/webbleen/go-gin/routers"
+	"github.com/webbleen/go-gin/routers/api"
 )
 
+// ingestCloseTimeout 退出时等待访问记录队列写完的最长时间
+const ingestCloseTimeout = 10 * time.Second
+
 func main() {
+	rebuildStats := flag.Bool("rebuild-daily-stats", false, "根据原始访问记录重建 daily_stats 后退出")
+	flag.Parse()
+
+	if *rebuildStats {
+		rebuildDailyStats()
+		return
+	}
+
 	// 打印配置信息
 	setting.PrintConfig()
 
@@ -50,8 +65,36 @@ func main() {
 		log.Printf("Actual pid is %d", syscall.Getpid())
 	}
 
+	// 收到退出信号后停止接收并写完队列中的访问记录；
+	// 重启（SIGHUP）时新进程会向旧进程发送 SIGTERM，旧进程同样在退出前写完
+	// 同时结束实时访客的 SSE 长连接，否则旧进程要等到连接超时才能退出
+	closeIngest := func() {
+		api.CloseLive()
+		api.CloseIngest(ingestCloseTimeout)
+	}
+	server.RegisterSignalHook(endless.POST_SIGNAL, syscall.SIGINT, closeIngest)
+	server.RegisterSignalHook(endless.POST_SIGNAL, syscall.SIGTERM, closeIngest)
+
 	err := server.ListenAndServe()
 	if err != nil {
 		log.Printf("Server err: %v", err)
 	}
+	// 所有请求处理完毕后再次确认队列已写完（可重复调用）
+	closeIngest()
+}
+
+// rebuildDailyStats 从 visit_record 回填 daily_stats，用于首次启用预聚合或修复统计
+func rebuildDailyStats() {
+	if err := database.InitDatabase(); err != nil {
+		log.Fatalf("数据库初始化失败: %v", err)
+	}
+	// 按报表时区重新划分日期
+	if err := database.SetReportTimeZone(setting.ReportTimeZone); err != nil {
+		log.Fatalf("报表时区 %q 无效: %v", setting.ReportTimeZone, err)
+	}
+	days, err := database.RebuildDailyStats()
+	if err != nil {
+		log.Fatalf("重建 daily_stats 失败（已完成 %d 天）: %v", days, err)
+	}
+	log.Printf("已重建 %d 天的 daily_stats", days)
 }
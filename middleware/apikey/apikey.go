@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/middleware/jwt"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// Header 传递 API Key 的请求头
+const Header = "X-API-Key"
+
+// ContextAPIKey 认证通过后写入 gin.Context 的 API Key key
+const ContextAPIKey = "auth_api_key"
+
+// RequireScope 要求请求携带具备指定权限范围的 API Key
+// 未携带 API Key 时回退到 JWT 认证，管理员令牌拥有全部权限
+func RequireScope(scope string) gin.HandlerFunc {
+	jwtAuth := jwt.JWT()
+	return func(c *gin.Context) {
+		plain := c.GetHeader(Header)
+		if plain == "" {
+			jwtAuth(c)
+			return
+		}
+
+		key, err := database.FindActiveAPIKey(plain)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code": e.ERROR_AUTH_API_KEY,
+				"msg":  e.GetMsg(e.ERROR_AUTH_API_KEY),
+				"data": gin.H{},
+			})
+			return
+		}
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": e.ERROR_AUTH_API_KEY_SCOPE,
+				"msg":  e.GetMsg(e.ERROR_AUTH_API_KEY_SCOPE),
+				"data": gin.H{"required_scope": scope},
+			})
+			return
+		}
+
+		c.Set(ContextAPIKey, key)
+		c.Next()
+	}
+}
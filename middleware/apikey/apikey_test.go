@@ -0,0 +1,84 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/util"
+	"gorm.io/gorm/logger"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DATABASE_URL", ":memory:")
+	if err := database.InitDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Logger = logger.Discard
+	setting.JwtSecret = "test-secret"
+	setting.JwtExpire = time.Hour
+
+	_, reader, err := database.CreateAPIKey("reader", "", []string{database.ScopeStatsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := util.GenerateToken("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/read", RequireScope(database.ScopeStatsRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/export", RequireScope(database.ScopeExport), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		path   string
+		key    string
+		bearer string
+		status int
+		code   int
+	}{
+		{name: "key with scope", path: "/read", key: reader, status: http.StatusOK},
+		{name: "key without scope", path: "/export", key: reader, status: http.StatusForbidden, code: e.ERROR_AUTH_API_KEY_SCOPE},
+		{name: "unknown key", path: "/read", key: "wbk_unknown", status: http.StatusUnauthorized, code: e.ERROR_AUTH_API_KEY},
+		{name: "admin token has every scope", path: "/export", bearer: token, status: http.StatusOK},
+		{name: "no credentials", path: "/read", status: http.StatusUnauthorized, code: e.ERROR_AUTH},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(Header, tt.key)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.code == 0 {
+				return
+			}
+			var body struct {
+				Code int `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.code {
+				t.Errorf("code = %d, want %d", body.Code, tt.code)
+			}
+		})
+	}
+}
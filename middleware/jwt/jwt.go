@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/util"
+)
+
+// ContextUsername 认证通过后写入 gin.Context 的用户名 key
+const ContextUsername = "auth_username"
+
+// JWT 校验访问令牌，令牌只能通过 Authorization: Bearer <token> 请求头传递
+func JWT() gin.HandlerFunc {
+	return auth(false)
+}
+
+// PageJWT 页面路由使用的令牌校验，浏览器直接打开页面时还可以通过 token 查询参数传递
+// 查询参数会出现在访问日志和浏览器历史中，只用于 Dashboard 页面，接口一律使用 JWT
+func PageJWT() gin.HandlerFunc {
+	return auth(true)
+}
+
+func auth(allowQuery bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := e.SUCCESS
+
+		token := GetToken(c)
+		if token == "" && allowQuery {
+			token = c.Query("token")
+		}
+		var claims *util.Claims
+		if token == "" {
+			code = e.ERROR_AUTH
+		} else {
+			var err error
+			claims, err = util.ParseToken(token)
+			switch {
+			case errors.Is(err, jwtlib.ErrTokenExpired):
+				code = e.ERROR_AUTH_CHECK_TOKEN_TIMEOUT
+			case err != nil:
+				code = e.ERROR_AUTH_CHECK_TOKEN_FAIL
+			}
+		}
+
+		if code != e.SUCCESS {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code": code,
+				"msg":  e.GetMsg(code),
+				"data": gin.H{},
+			})
+			return
+		}
+
+		c.Set(ContextUsername, claims.Username)
+		c.Next()
+	}
+}
+
+// GetToken 从 Authorization 请求头中取出令牌
+func GetToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+			return strings.TrimSpace(auth[len("bearer "):])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/util"
+)
+
+func TestJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setting.JwtSecret = "test-secret"
+	setting.JwtExpire = time.Hour
+
+	valid, _, err := util.GenerateToken("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, util.Claims{
+		Username: "admin",
+		RegisteredClaims: jwtlib.RegisteredClaims{
+			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte(setting.JwtSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, util.Claims{Username: "admin"}).
+		SignedString([]byte("another-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	username := func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextUsername))
+	}
+	r.GET("/protected", JWT(), username)
+	r.GET("/page", PageJWT(), username)
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		query    string
+		status   int
+		code     int
+		username string
+	}{
+		{name: "missing token", status: http.StatusUnauthorized, code: e.ERROR_AUTH},
+		{name: "bearer token", header: "Bearer " + valid, status: http.StatusOK, username: "admin"},
+		{name: "lowercase scheme", header: "bearer " + valid, status: http.StatusOK, username: "admin"},
+		// 接口不接受查询参数中的令牌，只有页面路由接受
+		{name: "query token", query: valid, status: http.StatusUnauthorized, code: e.ERROR_AUTH},
+		{name: "page query token", path: "/page", query: valid, status: http.StatusOK, username: "admin"},
+		{name: "page bearer token", path: "/page", header: "Bearer " + valid, status: http.StatusOK, username: "admin"},
+		{name: "page invalid query token", path: "/page", query: "not-a-token", status: http.StatusUnauthorized, code: e.ERROR_AUTH_CHECK_TOKEN_FAIL},
+		{name: "non-bearer header", header: "Basic " + valid, status: http.StatusUnauthorized, code: e.ERROR_AUTH},
+		{name: "expired token", header: "Bearer " + expired, status: http.StatusUnauthorized, code: e.ERROR_AUTH_CHECK_TOKEN_TIMEOUT},
+		{name: "wrong secret", header: "Bearer " + forged, status: http.StatusUnauthorized, code: e.ERROR_AUTH_CHECK_TOKEN_FAIL},
+		{name: "garbage", header: "Bearer not-a-token", status: http.StatusUnauthorized, code: e.ERROR_AUTH_CHECK_TOKEN_FAIL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/protected"
+			if tt.path != "" {
+				target = tt.path
+			}
+			if tt.query != "" {
+				target += "?token=" + tt.query
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusOK {
+				if w.Body.String() != tt.username {
+					t.Errorf("username = %q, want %q", w.Body.String(), tt.username)
+				}
+				return
+			}
+			var body struct {
+				Code int `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.code {
+				t.Errorf("code = %d, want %d", body.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestJWTRejectsDefaultSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setting.JwtSecret = "!@)*#)!@U#@*!@!)"
+	t.Cleanup(func() { setting.JwtSecret = "test-secret" })
+
+	if _, _, err := util.GenerateToken("admin"); err != util.ErrJwtSecretNotConfigured {
+		t.Errorf("GenerateToken error = %v, want %v", err, util.ErrJwtSecretNotConfigured)
+	}
+	// 用公开的默认密钥伪造的令牌
+	forged, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, util.Claims{
+		Username: "admin",
+		RegisteredClaims: jwtlib.RegisteredClaims{
+			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(setting.JwtSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/protected", JWT(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextUsername))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+forged)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
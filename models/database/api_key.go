@@ -0,0 +1,194 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API Key 权限范围
+const (
+	ScopeStatsRead  = "stats:read"
+	ScopeStatsWrite = "stats:write"
+	ScopeExport     = "export"
+)
+
+// AllScopes 所有可分配的权限范围
+var AllScopes = []string{ScopeStatsRead, ScopeStatsWrite, ScopeExport}
+
+// apiKeyPrefix 生成的明文 Key 前缀，便于在日志和配置中识别
+const apiKeyPrefix = "wbk_"
+
+// APIKey API 密钥模型，对应 init_db.sql 中的 api_keys 表
+// Key 列只保存明文密钥的 SHA-256，明文仅在创建时返回一次
+type APIKey struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Key         string         `gorm:"column:key;size:255;uniqueIndex;not null" json:"-"`
+	Prefix      string         `gorm:"size:20" json:"prefix"`
+	Name        string         `gorm:"size:255" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	Scopes      string         `gorm:"size:255" json:"scopes"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	LastUsedAt  *time.Time     `json:"last_used_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 沿用 init_db.sql 中的表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList 返回权限范围列表
+func (k *APIKey) ScopeList() []string {
+	return splitScopes(k.Scopes)
+}
+
+// HasScope 是否拥有指定权限范围
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAPIKey 计算明文密钥的存储值
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeScopes 校验并去重权限范围
+func NormalizeScopes(scopes []string) (string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !isKnownScope(scope) {
+			return "", fmt.Errorf("unknown scope: %s", scope)
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	return strings.Join(result, ","), nil
+}
+
+// CreateAPIKey 创建 API Key，返回模型和仅此一次可见的明文密钥
+func CreateAPIKey(name, description string, scopes []string) (*APIKey, string, error) {
+	scopeStr, err := NormalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + hex.EncodeToString(buf)
+
+	key := &APIKey{
+		Key:         HashAPIKey(plain),
+		Prefix:      plain[:len(apiKeyPrefix)+8],
+		Name:        name,
+		Description: description,
+		Scopes:      scopeStr,
+		IsActive:    true,
+	}
+	if err := DB.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+// GetAPIKeys 获取全部 API Key（不含已删除）
+func GetAPIKeys() ([]APIKey, error) {
+	var keys []APIKey
+	err := DB.Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// GetAPIKey 按 ID 获取 API Key
+func GetAPIKey(id uint) (*APIKey, error) {
+	var key APIKey
+	if err := DB.First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// UpdateAPIKey 更新 API Key 的名称、描述、权限范围或启用状态
+func UpdateAPIKey(id uint, data map[string]interface{}) (*APIKey, error) {
+	key, err := GetAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := DB.Model(key).Updates(data).Error; err != nil {
+		return nil, err
+	}
+	return GetAPIKey(id)
+}
+
+// DeleteAPIKey 软删除 API Key
+func DeleteAPIKey(id uint) error {
+	res := DB.Delete(&APIKey{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// apiKeyTouchInterval 最近使用时间的最小更新间隔，避免每个请求都写一次数据库
+const apiKeyTouchInterval = time.Minute
+
+// FindActiveAPIKey 根据明文密钥查找启用中的 API Key，并记录最近使用时间（最多每分钟更新一次）
+func FindActiveAPIKey(plain string) (*APIKey, error) {
+	var key APIKey
+	err := DB.Where("\"key\" = ? AND is_active = ?", HashAPIKey(plain), true).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// 更新失败不影响本次鉴权
+		if err := DB.Model(&key).UpdateColumn("last_used_at", now).Error; err != nil {
+			log.Printf("更新 API Key 最近使用时间失败: %v", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return &key, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func splitScopes(s string) []string {
+	var result []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    string
+		wantErr bool
+	}{
+		{nil, "", false},
+		{[]string{"stats:read"}, "stats:read", false},
+		{[]string{" stats:read ", "export", "stats:read", ""}, "stats:read,export", false},
+		{[]string{"stats:read", "admin"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeScopes(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeScopes(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	key := &APIKey{Scopes: "stats:read, export"}
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeStatsRead, true},
+		{ScopeExport, true},
+		{ScopeStatsWrite, false},
+		{"stats", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestFindActiveAPIKey(t *testing.T) {
+	setupTestDB(t)
+
+	key, plain, err := CreateAPIKey("ci", "", []string{ScopeStatsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(plain, apiKeyPrefix) || !strings.HasPrefix(plain, key.Prefix) {
+		t.Errorf("plain key %q does not start with prefix %q", plain, key.Prefix)
+	}
+
+	// 数据库中只保存哈希值
+	var stored APIKey
+	if err := DB.First(&stored, key.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Key == plain || stored.Key != HashAPIKey(plain) {
+		t.Errorf("stored key = %q, want the SHA-256 of the plain key", stored.Key)
+	}
+
+	found, err := FindActiveAPIKey(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != key.ID || found.LastUsedAt == nil {
+		t.Errorf("FindActiveAPIKey = %+v", found)
+	}
+
+	// 一分钟内的重复使用不再更新最近使用时间
+	lastUsed := *found.LastUsedAt
+	if found, err = FindActiveAPIKey(plain); err != nil {
+		t.Fatal(err)
+	}
+	if !found.LastUsedAt.Equal(lastUsed) {
+		t.Errorf("second lookup last_used_at = %v, want unchanged %v", found.LastUsedAt, lastUsed)
+	}
+	stale := time.Now().Add(-2 * apiKeyTouchInterval)
+	if err := DB.Model(&APIKey{}).Where("id = ?", key.ID).UpdateColumn("last_used_at", stale).Error; err != nil {
+		t.Fatal(err)
+	}
+	if found, err = FindActiveAPIKey(plain); err != nil {
+		t.Fatal(err)
+	}
+	if !found.LastUsedAt.After(stale.Add(apiKeyTouchInterval)) {
+		t.Errorf("stale lookup last_used_at = %v, want refreshed", found.LastUsedAt)
+	}
+
+	for _, candidate := range []string{"", stored.Key, plain + "x", strings.ToUpper(plain)} {
+		if _, err := FindActiveAPIKey(candidate); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("FindActiveAPIKey(%q) error = %v, want not found", candidate, err)
+		}
+	}
+
+	if _, err := UpdateAPIKey(key.ID, map[string]interface{}{"is_active": false}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindActiveAPIKey(plain); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("disabled key error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// GetBotStats 最近N天的机器人流量报告，天数不超过原始访问记录的保留期
+func GetBotStats(days int) (*response.BotStatsResult, error) {
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	days = retainedDays(days)
+	start := recentStart(days)
+
+	// 按天统计机器人/真人访问量
+	type dayRow struct {
+		Date  string
+		IsBot bool
+		Count int
+	}
+	var dayRows []dayRow
+	err := DB.Model(&VisitRecord{}).
+		Where(dateExpr("created_on")+" >= ?", start).
+		Select(dateExpr("created_on") + " as date, is_bot, COUNT(*) as count").
+		Group(dateExpr("created_on") + ", is_bot").
+		Scan(&dayRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 按机器人名称统计
+	var bots []response.BotStat
+	err = DB.Model(&VisitRecord{}).
+		Where(dateExpr("created_on")+" >= ?", start).
+		Where("is_bot = ?", true).
+		Select("bot_name as name, COUNT(*) as count").
+		Group("bot_name").
+		Order("count DESC").
+		Limit(20).
+		Scan(&bots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 机器人访问最多的页面
+	var pages []response.PageStat
+	err = DB.Model(&VisitRecord{}).
+		Where(dateExpr("created_on")+" >= ?", start).
+		Where("is_bot = ?", true).
+		Select("page, COUNT(*) as count").
+		Group("page").
+		Order("count DESC").
+		Limit(10).
+		Scan(&pages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	botMap := make(map[string]int)
+	humanMap := make(map[string]int)
+	res := &response.BotStatsResult{Days: days, Bots: bots, TopPages: pages}
+	for _, r := range dayRows {
+		res.TotalVisits += r.Count
+		if r.IsBot {
+			botMap[r.Date] += r.Count
+			res.BotVisits += r.Count
+		} else {
+			humanMap[r.Date] += r.Count
+		}
+	}
+	if res.TotalVisits > 0 {
+		res.BotShare = float64(res.BotVisits) / float64(res.TotalVisits)
+	}
+
+	startTime, _ := time.Parse("2006-01-02", start)
+	res.Points = make([]response.BotTrendPoint, 0, days)
+	for i := 0; i < days; i++ {
+		d := startTime.AddDate(0, 0, i).Format("2006-01-02")
+		res.Points = append(res.Points, response.BotTrendPoint{Date: d, Bots: botMap[d], Humans: humanMap[d]})
+	}
+	if res.Bots == nil {
+		res.Bots = []response.BotStat{}
+	}
+	if res.TopPages == nil {
+		res.TopPages = []response.PageStat{}
+	}
+	return res, nil
+}
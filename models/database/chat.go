@@ -0,0 +1,161 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+	"gorm.io/gorm"
+)
+
+// ChatSession 聊天会话，对应 init_db.sql 中的 chat_sessions 表
+type ChatSession struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	SessionID string         `gorm:"size:255;uniqueIndex;not null" json:"session_id"`
+	IP        string         `gorm:"column:ip_address;size:45" json:"-"`
+	UserAgent string         `gorm:"type:text" json:"-"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 沿用 init_db.sql 中的表名
+func (ChatSession) TableName() string {
+	return "chat_sessions"
+}
+
+// ChatMessage 聊天消息，对应 init_db.sql 中的 chat_messages 表
+type ChatMessage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SessionID string    `gorm:"size:255;index;not null" json:"session_id"`
+	Role      string    `gorm:"size:20;not null;check:role IN ('user', 'assistant')" json:"role"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	Type      string    `gorm:"size:50" json:"type"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 沿用 init_db.sql 中的表名
+func (ChatMessage) TableName() string {
+	return "chat_messages"
+}
+
+// CreateChatSession 创建聊天会话
+func CreateChatSession(ip, userAgent string) (*ChatSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	session := &ChatSession{
+		SessionID: hex.EncodeToString(buf),
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	if err := DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetChatSession 按会话ID获取会话
+func GetChatSession(sessionID string) (*ChatSession, error) {
+	var session ChatSession
+	if err := DB.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteChatSession 软删除会话并清除其消息
+func DeleteChatSession(sessionID string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("session_id = ?", sessionID).Delete(&ChatSession{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("session_id = ?", sessionID).Delete(&ChatMessage{}).Error
+	})
+}
+
+// AddChatMessage 追加一条消息，并刷新会话的更新时间
+func AddChatMessage(message *ChatMessage) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&ChatSession{}).
+			Where("session_id = ?", message.SessionID).
+			Update("updated_at", time.Now()).Error
+	})
+}
+
+// GetRecentChatMessages 获取会话最近的 limit 条消息（按时间正序）
+func GetRecentChatMessages(sessionID string, limit int) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	err := DB.Where("session_id = ?", sessionID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// GetChatMessages 分页获取会话消息（按时间正序）
+func GetChatMessages(sessionID string, page, pageSize int) (*response.ChatMessagesResult, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	// 限制每页最大数量
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	query := DB.Model(&ChatMessage{}).Where("session_id = ?", sessionID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var messages []ChatMessage
+	err := query.Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	responseMessages := make([]response.ChatMessage, 0, len(messages))
+	for _, m := range messages {
+		responseMessages = append(responseMessages, response.ChatMessage{
+			ID:        int(m.ID),
+			Role:      m.Role,
+			Content:   m.Content,
+			Type:      m.Type,
+			CreatedOn: m.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return &response.ChatMessagesResult{
+		SessionID: sessionID,
+		Messages:  responseMessages,
+		Pagination: response.Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	}, nil
+}
@@ -0,0 +1,313 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DailyStats 按天、语言、设备、国家、是否机器人预聚合的访问统计，在写入访问记录时增量更新
+// 访问统计、概览和趋势接口只读取该表，查询量与天数而非记录数相关
+// 并发写入时同一维度可能产生多行，读取时需要 SUM
+//
+// 独立访客/会话计入该访客当天（同语言、同机器人标记）第一次访问所在的行，按语言过滤时是精确值；
+// 另有 language 为 allLanguages 的行只记录跨语言去重的独立访客/会话（访问量为 0），
+// 不指定语言的查询读取这些行，避免同一访客在多个语言下被重复计入
+type DailyStats struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	Date           string `gorm:"size:10;index" json:"date"`
+	Language       string `gorm:"size:10" json:"language"`
+	Device         string `gorm:"size:50" json:"device"`
+	Country        string `gorm:"size:50" json:"country"`
+	IsBot          bool   `json:"is_bot"`
+	Visits         int    `json:"visits"`
+	UniqueVisitors int    `json:"unique_visitors"`
+	UniqueSessions int    `json:"unique_sessions"`
+}
+
+// DailyVisitor 每天（同语言或 allLanguages、同机器人标记）已出现过的访客和会话，写入访问记录时按唯一索引判断是否为新访客/新会话，
+// 不需要扫描当天的原始记录；与原始记录一起在汇总后删除，由 RebuildDailyStats 重建
+type DailyVisitor struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Date     string `gorm:"size:10;uniqueIndex:idx_daily_visitor" json:"date"`
+	Language string `gorm:"size:10;uniqueIndex:idx_daily_visitor" json:"language"`
+	IsBot    bool   `gorm:"uniqueIndex:idx_daily_visitor" json:"is_bot"`
+	Kind     string `gorm:"size:1;uniqueIndex:idx_daily_visitor" json:"kind"` // 见 seenVisitor、seenSession
+	Value    string `gorm:"size:100;uniqueIndex:idx_daily_visitor" json:"value"`
+}
+
+// DailyVisitor 的类型
+const (
+	seenVisitor = "v" // 独立访客，值为 visitorOf
+	seenSession = "s" // 会话，值为 session_id
+)
+
+// allLanguages 跨语言汇总行的 language 值；与概览的口径一致，只汇总有语言信息的访问
+const allLanguages = "*"
+
+// dailyKey DailyStats 的维度
+type dailyKey struct {
+	Date     string
+	Language string
+	Device   string
+	Country  string
+	IsBot    bool
+}
+
+func keyOf(record *VisitRecord) dailyKey {
+	return dailyKey{
+		Date:     record.CreatedOn.In(reportLoc).Format("2006-01-02"),
+		Language: record.Language,
+		Device:   record.Device,
+		Country:  record.Country,
+		IsBot:    record.IsBot,
+	}
+}
+
+// keysOf 访问记录计入的行：按语言划分的行，以及有语言信息时同一天、同机器人标记的跨语言汇总行
+// 汇总行不区分设备和国家
+func keysOf(key dailyKey) []dailyKey {
+	if key.Language == "" {
+		return []dailyKey{key}
+	}
+	return []dailyKey{key, {Date: key.Date, Language: allLanguages, IsBot: key.IsBot}}
+}
+
+// visitorOf 独立访客的去重依据，与 visitorExpr 一致
+func visitorOf(record *VisitRecord) string {
+	if record.IP == "" {
+		return record.SessionID
+	}
+	return record.IP
+}
+
+// incrementDailyStats 写入访问记录后在同一事务中更新 daily_stats
+// 按写入顺序登记到 daily_visitor，登记成功（之前未出现过）即为新访客/新会话；
+// 并发事务写入同一访客时唯一索引保证只有一个计数
+func incrementDailyStats(tx *gorm.DB, records []*VisitRecord) error {
+	type delta struct {
+		visits, visitors, sessions int
+	}
+	deltas := make(map[dailyKey]*delta)
+	var keys []dailyKey
+
+	for _, record := range records {
+		key := keyOf(record)
+		// 访问量只计入按语言划分的行，跨语言汇总行只记录独立访客/会话
+		for i, k := range keysOf(key) {
+			newVisitor, err := markSeen(tx, k, seenVisitor, visitorOf(record))
+			if err != nil {
+				return err
+			}
+			newSession, err := markSeen(tx, k, seenSession, record.SessionID)
+			if err != nil {
+				return err
+			}
+
+			d, ok := deltas[k]
+			if !ok {
+				d = &delta{}
+				deltas[k] = d
+				keys = append(keys, k)
+			}
+			if i == 0 {
+				d.visits++
+			}
+			d.visitors += boolToInt(newVisitor)
+			d.sessions += boolToInt(newSession)
+		}
+	}
+
+	for _, key := range keys {
+		d := deltas[key]
+		if d.visits == 0 && d.visitors == 0 && d.sessions == 0 {
+			continue
+		}
+		if err := addDailyStats(tx, key, d.visits, d.visitors, d.sessions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markSeen 登记当天出现的访客或会话，返回是否为第一次出现
+func markSeen(tx *gorm.DB, key dailyKey, kind, value string) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&DailyVisitor{
+		Date:     key.Date,
+		Language: key.Language,
+		IsBot:    key.IsBot,
+		Kind:     kind,
+		Value:    value,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// addDailyStats 累加某一维度的计数，不存在时新建一行
+func addDailyStats(tx *gorm.DB, key dailyKey, visits, visitors, sessions int) error {
+	var row DailyStats
+	err := tx.Where("date = ? AND language = ? AND device = ? AND country = ? AND is_bot = ?",
+		key.Date, key.Language, key.Device, key.Country, key.IsBot).
+		Limit(1).Find(&row).Error
+	if err != nil {
+		return err
+	}
+	if row.ID == 0 {
+		return tx.Create(&DailyStats{
+			Date:           key.Date,
+			Language:       key.Language,
+			Device:         key.Device,
+			Country:        key.Country,
+			IsBot:          key.IsBot,
+			Visits:         visits,
+			UniqueVisitors: visitors,
+			UniqueSessions: sessions,
+		}).Error
+	}
+	return tx.Model(&DailyStats{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+		"visits":          gorm.Expr("visits + ?", visits),
+		"unique_visitors": gorm.Expr("unique_visitors + ?", visitors),
+		"unique_sessions": gorm.Expr("unique_sessions + ?", sessions),
+	}).Error
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// RebuildDailyStats 根据原始访问记录重建 daily_stats，返回重建的天数
+// 只处理 visit_record 中仍有原始记录的日期；已汇总并删除原始记录的日期保持不变
+func RebuildDailyStats() (int, error) {
+	var dates []string
+	err := DB.Model(&VisitRecord{}).
+		Distinct(dateExpr("created_on")).
+		Order(dateExpr("created_on")).
+		Pluck(dateExpr("created_on"), &dates).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i, date := range dates {
+		if err := DB.Transaction(func(tx *gorm.DB) error {
+			return rebuildDate(tx, date)
+		}); err != nil {
+			return i, err
+		}
+	}
+	return len(dates), nil
+}
+
+// rebuildDate 按 ID（写入顺序）回放某一天的访问记录，与增量更新的计数规则一致
+// 异步写入时访问时间在入队时确定，与 ID 顺序不一定相同，FindInBatches 也只能按主键分页
+func rebuildDate(tx *gorm.DB, date string) error {
+	if err := tx.Where("date = ?", date).Delete(&DailyStats{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("date = ?", date).Delete(&DailyVisitor{}).Error; err != nil {
+		return err
+	}
+
+	type scope struct {
+		Language string
+		IsBot    bool
+		Value    string
+	}
+	visitors := make(map[scope]bool)
+	sessions := make(map[scope]bool)
+	stats := make(map[dailyKey]*DailyStats)
+	var keys []dailyKey
+
+	var batch []VisitRecord
+	err := tx.Model(&VisitRecord{}).
+		Where(dateExpr("created_on")+" = ?", date).
+		FindInBatches(&batch, 1000, func(_ *gorm.DB, _ int) error {
+			for i := range batch {
+				record := &batch[i]
+				key := keyOf(record)
+				key.Date = date
+				for j, k := range keysOf(key) {
+					v := scope{k.Language, k.IsBot, visitorOf(record)}
+					s := scope{k.Language, k.IsBot, record.SessionID}
+					newVisitor, newSession := !visitors[v], !sessions[s]
+					visitors[v], sessions[s] = true, true
+					if j > 0 && !newVisitor && !newSession {
+						continue
+					}
+
+					row, ok := stats[k]
+					if !ok {
+						row = &DailyStats{Date: date, Language: k.Language, Device: k.Device, Country: k.Country, IsBot: k.IsBot}
+						stats[k] = row
+						keys = append(keys, k)
+					}
+					if j == 0 {
+						row.Visits++
+					}
+					row.UniqueVisitors += boolToInt(newVisitor)
+					row.UniqueSessions += boolToInt(newSession)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	seen := make([]DailyVisitor, 0, len(visitors)+len(sessions))
+	for kind, set := range map[string]map[scope]bool{seenVisitor: visitors, seenSession: sessions} {
+		for s := range set {
+			seen = append(seen, DailyVisitor{Date: date, Language: s.Language, IsBot: s.IsBot, Kind: kind, Value: s.Value})
+		}
+	}
+	if len(seen) > 0 {
+		if err := tx.CreateInBatches(seen, 200).Error; err != nil {
+			return err
+		}
+	}
+
+	rows := make([]DailyStats, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, *stats[key])
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(rows, 200).Error
+}
+
+// sumDailyStats 汇总 daily_stats 中某一列
+func sumDailyStats(column string, scopes ...func(*gorm.DB) *gorm.DB) int {
+	var total int64
+	DB.Model(&DailyStats{}).
+		Scopes(scopes...).
+		Select("COALESCE(SUM(" + column + "), 0)").
+		Scan(&total)
+	return int(total)
+}
+
+// uniqueLanguage daily_stats 中独立访客/会话所在行的语言，未指定语言时为跨语言汇总行
+func uniqueLanguage(language string) string {
+	if language == "" {
+		return allLanguages
+	}
+	return language
+}
+
+// withDailyLanguage 限定 daily_stats 的语言，column 为要汇总的列：
+// 指定语言时按语言过滤；未指定时访问量汇总有语言信息的各语言行，独立访客/会话读取跨语言汇总行
+func withDailyLanguage(language, column string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if language == "" && column == "visits" {
+			return tx.Where("language IS NOT NULL AND language != '' AND language != ?", allLanguages)
+		}
+		return tx.Where("language = ?", uniqueLanguage(language))
+	}
+}
+
+// onDate 限定 daily_stats 的日期
+func onDate(date string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("date = ?", date)
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// visitAt 构造一条访问记录，访问时间为本地时区的 date 和 clock（HH:MM）
+func visitAt(date, clock, ip, session, language string, isBot bool) *VisitRecord {
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return &VisitRecord{
+		Model:     Model{CreatedOn: t},
+		IP:        ip,
+		SessionID: session,
+		Page:      "/posts/a/",
+		Language:  language,
+		Device:    "desktop",
+		Country:   "CN",
+		IsBot:     isBot,
+	}
+}
+
+// addVisits 分批写入访问记录，每个 batch 对应一次 AddVisitRecords
+func addVisits(t *testing.T, batches ...[]*VisitRecord) {
+	t.Helper()
+	for _, batch := range batches {
+		if err := AddVisitRecords(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// dailyTotals 按维度汇总 daily_stats，同一维度的多行合并
+func dailyTotals(t *testing.T) map[dailyKey][3]int {
+	t.Helper()
+	var rows []DailyStats
+	if err := DB.Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	totals := make(map[dailyKey][3]int)
+	for _, r := range rows {
+		key := dailyKey{r.Date, r.Language, r.Device, r.Country, r.IsBot}
+		v := totals[key]
+		totals[key] = [3]int{v[0] + r.Visits, v[1] + r.UniqueVisitors, v[2] + r.UniqueSessions}
+	}
+	return totals
+}
+
+func TestIncrementDailyStatsMatchesRebuild(t *testing.T) {
+	setupTestDB(t)
+
+	addVisits(t,
+		[]*VisitRecord{
+			visitAt("2024-03-04", "09:00", "1.1.1.1", "s1", "zh", false),
+			visitAt("2024-03-04", "09:05", "1.1.1.1", "s1", "zh", false),
+			visitAt("2024-03-04", "09:10", "2.2.2.2", "s2", "zh", false),
+		},
+		[]*VisitRecord{
+			// 同一访客的新会话、不同语言、无 IP 时按会话去重
+			visitAt("2024-03-04", "10:00", "1.1.1.1", "s3", "zh", false),
+			visitAt("2024-03-04", "10:05", "1.1.1.1", "s3", "en", false),
+			visitAt("2024-03-04", "10:10", "", "s4", "en", false),
+			visitAt("2024-03-04", "10:15", "", "s4", "en", false),
+			visitAt("2024-03-04", "10:20", "66.249.66.1", "bot", "zh", true),
+		},
+		[]*VisitRecord{
+			// 次日重新计数
+			visitAt("2024-03-05", "08:00", "1.1.1.1", "s1", "zh", false),
+		},
+	)
+
+	want := map[dailyKey][3]int{
+		{"2024-03-04", "zh", "desktop", "CN", false}: {4, 2, 3},
+		{"2024-03-04", "en", "desktop", "CN", false}: {3, 2, 2},
+		{"2024-03-04", "zh", "desktop", "CN", true}:  {1, 1, 1},
+		{"2024-03-05", "zh", "desktop", "CN", false}: {1, 1, 1},
+		// 跨语言汇总行：1.1.1.1 在 zh 和 en 下只计一次
+		{"2024-03-04", allLanguages, "", "", false}: {0, 3, 4},
+		{"2024-03-04", allLanguages, "", "", true}:  {0, 1, 1},
+		{"2024-03-05", allLanguages, "", "", false}: {0, 1, 1},
+	}
+	incremental := dailyTotals(t)
+	if !reflect.DeepEqual(incremental, want) {
+		t.Errorf("incremental daily_stats = %v, want %v", incremental, want)
+	}
+
+	days, err := RebuildDailyStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days != 2 {
+		t.Errorf("RebuildDailyStats rebuilt %d days, want 2", days)
+	}
+	if rebuilt := dailyTotals(t); !reflect.DeepEqual(rebuilt, incremental) {
+		t.Errorf("rebuilt daily_stats = %v, incremental = %v", rebuilt, incremental)
+	}
+
+	// 重建后继续增量写入，已出现过的访客和会话不重复计数
+	addVisits(t, []*VisitRecord{
+		visitAt("2024-03-05", "09:00", "1.1.1.1", "s1", "zh", false),
+		visitAt("2024-03-05", "09:05", "3.3.3.3", "s5", "zh", false),
+	})
+	key := dailyKey{"2024-03-05", "zh", "desktop", "CN", false}
+	if got, want := dailyTotals(t)[key], [3]int{3, 2, 2}; got != want {
+		t.Errorf("daily_stats after rebuild and increment = %v, want %v", got, want)
+	}
+}
+
+func TestUniqueVisitorsAcrossLanguages(t *testing.T) {
+	setupTestDB(t)
+
+	date := time.Now().In(reportLoc).Format("2006-01-02")
+	addVisits(t, []*VisitRecord{
+		visitAt(date, "00:00", "1.1.1.1", "s1", "zh", false),
+		visitAt(date, "00:00", "1.1.1.1", "s1", "en", false),
+		visitAt(date, "00:00", "2.2.2.2", "s2", "en", false),
+		// 没有语言信息的访问不计入概览
+		visitAt(date, "00:00", "3.3.3.3", "s3", "", false),
+	})
+
+	tests := []struct {
+		language                   string
+		visits, visitors, sessions int
+	}{
+		{"", 3, 2, 2},
+		{"zh", 1, 1, 1},
+		{"en", 2, 2, 2},
+	}
+	for _, tt := range tests {
+		visits := GetTodayVisits(tt.language, false, reportLoc)
+		visitors := GetUniqueVisitorsToday(tt.language, false, reportLoc)
+		sessions := GetTodayUniqueSessions(tt.language, false, reportLoc)
+		if visits != tt.visits || visitors != tt.visitors || sessions != tt.sessions {
+			t.Errorf("language %q: visits, visitors, sessions = %d, %d, %d, want %d, %d, %d",
+				tt.language, visits, visitors, sessions, tt.visits, tt.visitors, tt.sessions)
+		}
+	}
+
+	start, _ := time.ParseInLocation("2006-01-02", date, reportLoc)
+	res, err := GetTrend(start, start, GranularityDay, false, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.Points[0]; p.UniqueVisitors != 2 || p.UniqueSessions != 2 {
+		t.Errorf("trend point = %+v, want 2 visitors, 2 sessions", p)
+	}
+	if _, ok := countVisitsBy("language", false)[allLanguages]; ok {
+		t.Error("language stats include the all-languages rows")
+	}
+}
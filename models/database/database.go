@@ -3,9 +3,11 @@ package database
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
@@ -25,8 +27,12 @@ func InitDatabase() error {
 		return fmt.Errorf("DATABASE_URL environment variable is required")
 	}
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(databaseURL), &gorm.Config{
+	dialector, err := openDialector(databaseURL)
+	if err != nil {
+		return err
+	}
+
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // 保持表名单数形式
@@ -37,10 +43,27 @@ func InitDatabase() error {
 		return err
 	}
 
+	if isSQLite() {
+		// SQLite 只允许单个写连接，避免并发写入时出现 database is locked
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.SetMaxOpenConns(1)
+		}
+	}
+
 	// Auto migrate the schema
 	if err := DB.AutoMigrate(
 		&VisitRecord{},
 		&ContentStats{},
+		&DailyStats{},
+		&DailyVisitor{},
+		&VisitRollup{},
+		&VisitRecordArchive{},
+		&Event{},
+		&APIKey{},
+		&Tool{},
+		&ToolUsage{},
+		&ChatSession{},
+		&ChatMessage{},
 	); err != nil {
 		return err
 	}
@@ -48,6 +71,58 @@ func InitDatabase() error {
 	return nil
 }
 
+// Ping 检查数据库连接是否可用
+func Ping() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// libpqKeys key=value DSN 可用的 libpq 连接参数
+var libpqKeys = map[string]bool{
+	"host": true, "hostaddr": true, "port": true, "dbname": true, "user": true, "password": true,
+	"passfile": true, "sslmode": true, "sslcert": true, "sslkey": true, "sslrootcert": true,
+	"connect_timeout": true, "application_name": true, "options": true, "search_path": true,
+	"target_session_attrs": true, "service": true,
+}
+
+// openDialector selects the GORM driver from the DATABASE_URL scheme
+//
+//	postgres://... / postgresql://... / host=... user=... → PostgreSQL
+//	sqlite://path/to.db / file:... / :memory:             → SQLite
+//
+// A key=value DSN must start with a known libpq key; anything else is rejected.
+func openDialector(databaseURL string) (gorm.Dialector, error) {
+	switch {
+	case strings.HasPrefix(databaseURL, "postgres://"),
+		strings.HasPrefix(databaseURL, "postgresql://"),
+		isKeyValueDSN(databaseURL):
+		return postgres.Open(databaseURL), nil
+	case strings.HasPrefix(databaseURL, "sqlite://"):
+		path := strings.TrimPrefix(databaseURL, "sqlite://")
+		if path == "" {
+			return nil, fmt.Errorf("DATABASE_URL %q is missing the sqlite file path", databaseURL)
+		}
+		return sqlite.Open(path), nil
+	case strings.HasPrefix(databaseURL, "file:"),
+		strings.HasPrefix(databaseURL, ":memory:"):
+		return sqlite.Open(databaseURL), nil
+	}
+	return nil, fmt.Errorf("unsupported DATABASE_URL scheme: %s", databaseURL)
+}
+
+// isKeyValueDSN reports whether dsn is a libpq key=value connection string
+func isKeyValueDSN(dsn string) bool {
+	fields := strings.Fields(dsn)
+	if len(fields) == 0 {
+		return false
+	}
+	key, _, ok := strings.Cut(fields[0], "=")
+	return ok && libpqKeys[key]
+}
+
 // GetDB returns the database connection
 func GetDB() *gorm.DB {
 	return DB
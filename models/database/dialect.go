@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// isSQLite 当前连接是否为 SQLite
+func isSQLite() bool {
+	return DB != nil && DB.Dialector.Name() == "sqlite"
+}
+
+// dateExpr 返回把时间列格式化为报表时区 YYYY-MM-DD 文本的 SQL 表达式
+func dateExpr(column string) string {
+	return dateExprIn(column, reportLoc)
+}
+
+// hourExpr 返回把时间列格式化为报表时区 YYYY-MM-DD HH 文本的 SQL 表达式
+func hourExpr(column string) string {
+	return hourExprIn(column, reportLoc)
+}
+
+// dateExprIn 返回把时间列格式化为 loc 时区 YYYY-MM-DD 文本的 SQL 表达式
+// PostgreSQL 的 DATE() 返回 date 类型，扫描到 string 时会变成 RFC3339 格式，
+// 因此两种方言都统一输出文本，保证与 Go 侧的日期 key 一致。
+// loc 为服务器本地时区时：SQLite 中时间以 "2006-01-02 15:04:05.999999999-07:00" 文本写入，
+// 直接截取前 10 位即为写入时的本地日期；PostgreSQL 使用会话时区。
+// 其他时区：PostgreSQL 用 AT TIME ZONE 换算；SQLite 没有时区数据库，
+// strftime 先按文本中的偏移换算成 UTC，再加上该时区当前的 UTC 偏移（不处理历史上的夏令时切换）
+func dateExprIn(column string, loc *time.Location) string {
+	return timeExprIn(column, loc, "%Y-%m-%d", "YYYY-MM-DD", 10)
+}
+
+// hourExprIn 返回把时间列格式化为 loc 时区 YYYY-MM-DD HH 文本的 SQL 表达式，规则同 dateExprIn
+func hourExprIn(column string, loc *time.Location) string {
+	return timeExprIn(column, loc, "%Y-%m-%d %H", "YYYY-MM-DD HH24", 13)
+}
+
+func timeExprIn(column string, loc *time.Location, sqliteFormat, pgFormat string, length int) string {
+	if loc == time.Local {
+		if isSQLite() {
+			return "substr(" + column + ", 1, " + strconv.Itoa(length) + ")"
+		}
+		return "TO_CHAR(" + column + ", '" + pgFormat + "')"
+	}
+	if isSQLite() {
+		_, offset := time.Now().In(loc).Zone()
+		return "strftime('" + sqliteFormat + "', " + column + ", '" + fmt.Sprintf("%+d", offset/60) + " minutes')"
+	}
+	// loc 由 LoadLocation 校验过，名称只含安全字符
+	return "TO_CHAR(" + column + " AT TIME ZONE '" + loc.String() + "', '" + pgFormat + "')"
+}
+
+// visitorExpr 独立访客的去重依据：IP（完整、截断或哈希后的值），
+// 隐私模式为 drop 时 IP 为空，退化为按 session_id 去重
+func visitorExpr() string {
+	return "CASE WHEN ip IS NULL OR ip = '' THEN session_id ELSE ip END"
+}
+
+// greatestExpr 取列与参数中的较大值，用于只增不减的累计值
+func greatestExpr(column string) string {
+	if isSQLite() {
+		return "MAX(" + column + ", ?)"
+	}
+	return "GREATEST(" + column + ", ?)"
+}
+
+// jsonFieldExpr 读取 JSON 文本列中某个顶层字段的文本值，字段不存在时为空字符串
+// key 会直接拼入 SQL，调用方需先用 validJSONKey 校验
+func jsonFieldExpr(column, key string) string {
+	if isSQLite() {
+		return "COALESCE(CAST(json_extract(" + column + ", '$.\"" + key + "\"') AS TEXT), '')"
+	}
+	return "COALESCE(CAST(" + column + " AS jsonb) ->> '" + key + "', '')"
+}
+
+// validJSONKey 只允许字母、数字、下划线和连字符
+func validJSONKey(key string) bool {
+	if key == "" || len(key) > 50 {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+	"gorm.io/gorm"
+)
+
+// CompletionDepth 滚动深度达到该百分比视为读完
+const CompletionDepth = 90
+
+// Engagement 页面停留时长和滚动深度上报，按会话 + 页面更新当天的访问记录
+// 前端上报的是累计值，多次上报取最大值，丢失中间的心跳不影响结果
+type Engagement struct {
+	SessionID   string    `json:"session_id"`
+	Page        string    `json:"page"`
+	Duration    int       `json:"duration"`     // 累计停留秒数
+	ScrollDepth int       `json:"scroll_depth"` // 最大滚动深度（0-100）
+	Time        time.Time `json:"time"`
+}
+
+// PrepareEngagement 规范化页面路径并记录上报时间（决定更新哪一天的访问记录）
+func PrepareEngagement(engagement *Engagement) {
+	engagement.Page = NormalizePage(engagement.Page)
+	engagement.Time = time.Now()
+}
+
+// UpdateEngagement 同步更新一条停留时长和滚动深度
+func UpdateEngagement(engagement *Engagement) error {
+	PrepareEngagement(engagement)
+	_, err := UpdateEngagements([]*Engagement{engagement})
+	return err
+}
+
+// UpdateEngagements 在一个事务中批量更新已经过 PrepareEngagement 处理的上报，
+// 同一访问的多次上报先合并为最大值；返回找不到访问记录（未记录或尚未写入）的上报，合并后每个访问一条
+func UpdateEngagements(engagements []*Engagement) ([]*Engagement, error) {
+	type key struct {
+		Date, SessionID, Page string
+	}
+	merged := make(map[key]*Engagement)
+	var keys []key
+	for _, e := range engagements {
+		k := key{e.Time.In(reportLoc).Format("2006-01-02"), e.SessionID, e.Page}
+		m, ok := merged[k]
+		if !ok {
+			copied := *e
+			merged[k] = &copied
+			keys = append(keys, k)
+			continue
+		}
+		m.Duration = max(m.Duration, e.Duration)
+		m.ScrollDepth = max(m.ScrollDepth, e.ScrollDepth)
+	}
+
+	var unmatched []*Engagement
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, k := range keys {
+			m := merged[k]
+			result := tx.Model(&VisitRecord{}).
+				Where("session_id = ? AND page = ? AND "+dateExpr("created_on")+" = ?", k.SessionID, k.Page, k.Date).
+				Updates(map[string]interface{}{
+					"duration":     gorm.Expr(greatestExpr("duration"), m.Duration),
+					"scroll_depth": gorm.Expr(greatestExpr("scroll_depth"), m.ScrollDepth),
+					"modified_on":  time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				unmatched = append(unmatched, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unmatched, nil
+}
+
+// engagementRow 按页面等维度汇总的阅读数据，visit_rollup 中保存同样的计数
+type engagementRow struct {
+	Page      string
+	Visits    int
+	Engaged   int // 上报过时长或滚动深度的访问
+	Duration  int // 停留秒数合计
+	Completed int // 滚动深度达到 CompletionDepth 的访问
+	Bounces   int // 会话当天只浏览了这一个页面的访问
+}
+
+// engagementSelect 与 engagementRow 对应的聚合列，需配合 withSessionPages 使用
+func engagementSelect() string {
+	return "COUNT(*) as visits, " +
+		"SUM(CASE WHEN duration > 0 OR scroll_depth > 0 THEN 1 ELSE 0 END) as engaged, " +
+		"COALESCE(SUM(duration), 0) as duration, " +
+		"SUM(CASE WHEN scroll_depth >= " + strconv.Itoa(CompletionDepth) + " THEN 1 ELSE 0 END) as completed, " +
+		"SUM(CASE WHEN s.n = 1 THEN 1 ELSE 0 END) as bounces"
+}
+
+// withSessionPages 关联每个会话当天浏览的页面数（s.n），scopes 同时作用于访问记录和会话统计
+func withSessionPages(tx *gorm.DB, scopes ...func(*gorm.DB) *gorm.DB) *gorm.DB {
+	sessions := tx.Model(&VisitRecord{}).
+		Scopes(scopes...).
+		Select("session_id, " + dateExpr("created_on") + " as d, COUNT(*) as n").
+		Group("session_id, " + dateExpr("created_on"))
+	return tx.Model(&VisitRecord{}).
+		Scopes(scopes...).
+		Joins("JOIN (?) s ON s.session_id = visit_record.session_id AND s.d = "+dateExpr("visit_record.created_on"), sessions)
+}
+
+// GetPageEngagement 按访问量降序返回页面的平均阅读时长、跳出率和读完率，合并已汇总的历史数据
+func GetPageEngagement(limit int, startDate, endDate string, language string, includeBots bool) ([]response.PageEngagementStat, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	dateRange := func(column string) func(*gorm.DB) *gorm.DB {
+		return func(tx *gorm.DB) *gorm.DB {
+			if startDate != "" {
+				tx = tx.Where(column+" >= ?", startDate)
+			}
+			if endDate != "" {
+				tx = tx.Where(column+" <= ?", endDate)
+			}
+			return tx
+		}
+	}
+
+	// 原始记录和汇总数据合并后再排序取前 limit 个
+	sums := "SUM(visits) as visits, SUM(engaged) as engaged, SUM(duration) as duration, SUM(completed) as completed, SUM(bounces) as bounces"
+	var rows []engagementRow
+	err := unionAll(
+		withSessionPages(DB, withBots(includeBots), withLanguage(language), dateRange(dateExpr("created_on"))).
+			Select("page, "+engagementSelect()).
+			Group("page"),
+		DB.Model(&VisitRollup{}).
+			Scopes(withBots(includeBots), withLanguage(language), dateRange("date")).
+			Select("page, "+sums).
+			Group("page"),
+	).
+		Select("page, " + sums).
+		Group("page").
+		Order("visits DESC, page").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]response.PageEngagementStat, 0, len(rows))
+	for _, t := range rows {
+		stat := response.PageEngagementStat{Page: t.Page, Visits: t.Visits}
+		if t.Engaged > 0 {
+			stat.AvgDuration = float64(t.Duration) / float64(t.Engaged)
+			stat.CompletionRate = float64(t.Completed) / float64(t.Engaged)
+		}
+		if t.Visits > 0 {
+			stat.BounceRate = float64(t.Bounces) / float64(t.Visits)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+	"github.com/webbleen/go-gin/pkg/privacy"
+	"gorm.io/gorm"
+)
+
+// Event 自定义事件，如外链点击、代码复制、站内搜索
+// Properties 为 JSON 对象文本，按属性分组统计时通过 jsonFieldExpr 读取
+type Event struct {
+	Model
+	Name       string  `json:"name" gorm:"size:100;index"`
+	Properties string  `json:"properties" gorm:"type:text"`
+	Value      float64 `json:"value"`
+	Page       string  `json:"page" gorm:"size:200"`
+	SessionID  string  `json:"session_id" gorm:"size:100"`
+	Language   string  `json:"language" gorm:"size:10"`
+	IP         string  `json:"ip" gorm:"size:45"`
+	Country    string  `json:"country" gorm:"size:50"`
+	Device     string  `json:"device" gorm:"size:50"`
+	IsBot      bool    `json:"is_bot" gorm:"default:false;index"`
+}
+
+// ErrInvalidProperty 属性名不合法
+var ErrInvalidProperty = errors.New("invalid property name")
+
+// BeforeCreate 保留入队时记录的事件时间
+func (event *Event) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now()
+	if event.CreatedOn.IsZero() {
+		event.CreatedOn = now
+	}
+	event.ModifiedOn = now
+	return nil
+}
+
+// PrepareEvent 存储前的规范化，与 PrepareVisitRecord 规则一致
+func PrepareEvent(event *Event) {
+	event.ID = 0
+	event.CreatedOn = time.Now()
+	event.Page = NormalizePage(event.Page)
+	event.IP = privacy.AnonymizeIP(event.IP, event.CreatedOn.In(reportLoc))
+	event.Name = clip(event.Name, 100)
+	event.SessionID = clip(event.SessionID, 100)
+	event.Language = clip(event.Language, 10)
+	event.Country = clip(event.Country, 50)
+	event.Device = clip(event.Device, 50)
+	if event.Properties == "" {
+		event.Properties = "{}"
+	}
+}
+
+// AddEvent 同步写入一条事件
+func AddEvent(event *Event) error {
+	PrepareEvent(event)
+	return AddEvents([]*Event{event})
+}
+
+// AddEvents 批量写入已经过 PrepareEvent 处理的事件
+func AddEvents(events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	err := DB.CreateInBatches(events, 200).Error
+	if err != nil {
+		for _, event := range events {
+			event.ID = 0
+		}
+	}
+	return err
+}
+
+// eventQuery 事件查询的公共过滤条件
+func eventQuery(startDate, endDate, language string, includeBots bool) *gorm.DB {
+	query := DB.Model(&Event{}).Scopes(withBots(includeBots), withLanguage(language))
+	if startDate != "" {
+		query = query.Where(dateExpr("created_on")+" >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where(dateExpr("created_on")+" <= ?", endDate)
+	}
+	return query
+}
+
+// GetTopEvents 按次数降序返回事件名称
+func GetTopEvents(limit int, startDate, endDate string, language string, includeBots bool) ([]response.EventStat, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	stats := make([]response.EventStat, 0, limit)
+	err := eventQuery(startDate, endDate, language, includeBots).
+		Select("name, COUNT(*) as count").
+		Group("name").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetEventStats 单个事件最近N天的趋势；property 不为空时同时按该属性的取值分组统计
+func GetEventStats(name, property string, days int, language string, includeBots bool) (*response.EventStatsResult, error) {
+	if property != "" && !validJSONKey(property) {
+		return nil, ErrInvalidProperty
+	}
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	start := recentStart(days)
+	query := func() *gorm.DB {
+		return eventQuery(start, "", language, includeBots).Where("name = ?", name)
+	}
+
+	type row struct {
+		Date           string
+		Count          int
+		UniqueSessions int
+		Value          float64
+	}
+	var rows []row
+	err := query().
+		Select(dateExpr("created_on") + " as date, COUNT(*) as count, COUNT(DISTINCT session_id) as unique_sessions, COALESCE(SUM(value), 0) as value").
+		Group(dateExpr("created_on")).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.EventStatsResult{Name: name, Property: property}
+	byDate := make(map[string]row, len(rows))
+	for _, r := range rows {
+		byDate[r.Date] = r
+		res.Total += r.Count
+		res.Value += r.Value
+	}
+	startTime, _ := time.Parse("2006-01-02", start)
+	res.Points = make([]response.EventTrendPoint, 0, days)
+	for i := 0; i < days; i++ {
+		d := startTime.AddDate(0, 0, i).Format("2006-01-02")
+		r := byDate[d]
+		res.Points = append(res.Points, response.EventTrendPoint{
+			Date:           d,
+			Count:          r.Count,
+			UniqueSessions: r.UniqueSessions,
+			Value:          r.Value,
+		})
+	}
+
+	if property != "" {
+		field := jsonFieldExpr("properties", property)
+		res.Values = make([]response.EventPropertyStat, 0)
+		err = query().
+			Select(field + " as value, COUNT(*) as count, COALESCE(SUM(value), 0) as sum").
+			Group(field).
+			Order("count DESC").
+			Limit(100).
+			Scan(&res.Values).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+func TestGetEventStatsByProperty(t *testing.T) {
+	setupTestDB(t)
+
+	events := []*Event{
+		{Name: "outbound", Properties: `{"href":"https://a.example"}`, Value: 1, SessionID: "s1"},
+		{Name: "outbound", Properties: `{"href":"https://a.example"}`, Value: 2, SessionID: "s2"},
+		{Name: "outbound", Properties: `{"href":"https://b.example"}`, Value: 3, SessionID: "s1"},
+		{Name: "outbound", SessionID: "s3"},
+		{Name: "outbound", Properties: `{"href":"https://a.example"}`, SessionID: "bot", IsBot: true},
+		{Name: "copy", Properties: `{"href":"https://a.example"}`, SessionID: "s1"},
+	}
+	for _, event := range events {
+		if err := AddEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := GetEventStats("outbound", "href", 7, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 4 || res.Value != 6 || len(res.Points) != 7 {
+		t.Errorf("total = %d, value = %v, points = %d", res.Total, res.Value, len(res.Points))
+	}
+	if last := res.Points[6]; last.Count != 4 || last.UniqueSessions != 3 {
+		t.Errorf("today = %+v", last)
+	}
+	want := []response.EventPropertyStat{
+		{Value: "https://a.example", Count: 2, Sum: 3},
+		{Value: "", Count: 1, Sum: 0},
+		{Value: "https://b.example", Count: 1, Sum: 3},
+	}
+	got := res.Values
+	// 次数相同的取值顺序不固定
+	if len(got) == 3 && got[1].Value > got[2].Value {
+		got[1], got[2] = got[2], got[1]
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %+v, want %+v", got, want)
+	}
+
+	for _, property := range []string{"a b", "href'); DROP TABLE event; --", "a.b"} {
+		if _, err := GetEventStats("outbound", property, 7, "", false); !errors.Is(err, ErrInvalidProperty) {
+			t.Errorf("GetEventStats(property %q) error = %v, want ErrInvalidProperty", property, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// 漏斗和路径分析的限制
+const (
+	funnelMaxSteps = 10
+	pathMaxDepth   = 5
+)
+
+// ErrInvalidFunnel 漏斗步骤为空或过多
+var ErrInvalidFunnel = errors.New("funnel needs 1 to 10 steps")
+
+// pagePattern 页面匹配规则：* 匹配任意字符（包括 /），其余按字面匹配
+type pagePattern struct {
+	raw string
+	re  *regexp.Regexp
+}
+
+func compilePattern(p string) pagePattern {
+	parts := strings.Split(ParseURL(p), "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return pagePattern{raw: p, re: regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")}
+}
+
+func (p pagePattern) match(page string) bool {
+	return p.re.MatchString(page)
+}
+
+// GetFunnel 按会话统计依次到达每个步骤的会话数；步骤需按顺序出现，中间可以有其他页面
+func GetFunnel(steps []string, startDate, endDate, language string, includeBots bool) (*response.FunnelResult, error) {
+	if len(steps) == 0 || len(steps) > funnelMaxSteps {
+		return nil, ErrInvalidFunnel
+	}
+	patterns := make([]pagePattern, len(steps))
+	for i, step := range steps {
+		patterns[i] = compilePattern(step)
+	}
+
+	reached := make([]int, len(patterns))
+	err := reconstructSessions(startDate, endDate, language, includeBots, func(s *session) {
+		next := 0
+		for _, page := range s.pages {
+			if next < len(patterns) && patterns[next].match(page) {
+				reached[next]++
+				next++
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.FunnelResult{Steps: make([]response.FunnelStep, len(patterns))}
+	for i, p := range patterns {
+		step := response.FunnelStep{Pattern: p.raw, Sessions: reached[i]}
+		if i > 0 {
+			step.DropOff = reached[i-1] - reached[i]
+			if reached[i-1] > 0 {
+				step.Conversion = float64(reached[i]) / float64(reached[i-1])
+			}
+		} else if reached[0] > 0 {
+			step.Conversion = 1
+		}
+		if reached[0] > 0 {
+			step.OverallConversion = float64(reached[i]) / float64(reached[0])
+		}
+		res.Steps[i] = step
+	}
+	return res, nil
+}
+
+// GetPaths 从 from 页面出发，会话中随后访问的前 depth 个页面组成的最常见路径
+// 会话在此之后结束时路径较短，只访问了 from 的会话对应只含 from 的路径
+func GetPaths(from string, depth, limit int, startDate, endDate, language string, includeBots bool) (*response.PathResult, error) {
+	if depth <= 0 || depth > pathMaxDepth {
+		depth = 3
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	pattern := compilePattern(from)
+
+	counts := make(map[string]int)
+	total := 0
+	err := reconstructSessions(startDate, endDate, language, includeBots, func(s *session) {
+		for i, page := range s.pages {
+			if !pattern.match(page) {
+				continue
+			}
+			end := min(i+1+depth, len(s.pages))
+			// 页面路径不含换行，用作路径的 key
+			counts[strings.Join(s.pages[i:end], "\n")]++
+			total++
+			return
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.PathResult{From: from, Sessions: total, Paths: make([]response.PathStat, 0, len(counts))}
+	for key, count := range counts {
+		res.Paths = append(res.Paths, response.PathStat{Pages: strings.Split(key, "\n"), Count: count})
+	}
+	sort.Slice(res.Paths, func(i, j int) bool {
+		a, b := res.Paths[i], res.Paths[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return strings.Join(a.Pages, "\n") < strings.Join(b.Pages, "\n")
+	})
+	if len(res.Paths) > limit {
+		res.Paths = res.Paths[:limit]
+	}
+	return res, nil
+}
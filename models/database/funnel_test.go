@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		page    string
+		want    bool
+	}{
+		{"/pricing/", "/pricing/", true},
+		{"/pricing/", "/pricing/plans/", false},
+		{"/posts/*", "/posts/a/", true},
+		{"/posts/*", "/posts/2024/03/a/", true},
+		{"/posts/*", "/post/a/", false},
+		{"*/comments/", "/posts/a/comments/", true},
+		{"/a*b/", "/ab/", true},
+		// 正则元字符按字面匹配
+		{"/a.b/", "/a.b/", true},
+		{"/a.b/", "/axb/", false},
+		{"/(draft)/v[1]/", "/(draft)/v[1]/", true},
+		{"/(draft)/v[1]/", "/draft/v1/", false},
+		// 完整 URL 只保留路径
+		{"https://example.com/posts/*", "/posts/a/", true},
+	}
+	for _, tt := range tests {
+		if got := compilePattern(tt.pattern).match(tt.page); got != tt.want {
+			t.Errorf("compilePattern(%q).match(%q) = %v, want %v", tt.pattern, tt.page, got, tt.want)
+		}
+	}
+}
+
+func TestGetFunnel(t *testing.T) {
+	setupTestDB(t)
+
+	addVisits(t, []*VisitRecord{
+		// 按顺序完成全部步骤，中间夹杂其他页面
+		pageVisit("10:00", "s1", "/"),
+		pageVisit("10:01", "s1", "/about/"),
+		pageVisit("10:02", "s1", "/posts/a/"),
+		pageVisit("10:03", "s1", "/pricing/"),
+		// 顺序颠倒时只到达第一步
+		pageVisit("10:00", "s2", "/pricing/"),
+		pageVisit("10:01", "s2", "/"),
+		// 首页之后离开
+		pageVisit("10:00", "s3", "/"),
+		// 会话超时后的访问不计入前一个会话
+		pageVisit("10:00", "s4", "/"),
+		pageVisit("11:00", "s4", "/posts/b/"),
+	})
+
+	res, err := GetFunnel([]string{"/", "/posts/*", "/pricing/"}, "2024-03-04", "2024-03-04", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []response.FunnelStep{
+		{Pattern: "/", Sessions: 4, Conversion: 1, OverallConversion: 1},
+		{Pattern: "/posts/*", Sessions: 1, DropOff: 3, Conversion: 0.25, OverallConversion: 0.25},
+		{Pattern: "/pricing/", Sessions: 1, Conversion: 1, OverallConversion: 0.25},
+	}
+	if !reflect.DeepEqual(res.Steps, want) {
+		t.Errorf("GetFunnel = %+v, want %+v", res.Steps, want)
+	}
+
+	paths, err := GetPaths("/", 2, 10, "2024-03-04", "2024-03-04", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPaths := &response.PathResult{From: "/", Sessions: 4, Paths: []response.PathStat{
+		{Pages: []string{"/"}, Count: 3},
+		{Pages: []string{"/", "/about/", "/posts/a/"}, Count: 1},
+	}}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("GetPaths = %+v, want %+v", paths, wantPaths)
+	}
+
+	for _, steps := range [][]string{nil, make([]string, funnelMaxSteps+1)} {
+		if _, err := GetFunnel(steps, "", "", "", false); !errors.Is(err, ErrInvalidFunnel) {
+			t.Errorf("GetFunnel(%d steps) error = %v, want ErrInvalidFunnel", len(steps), err)
+		}
+	}
+}
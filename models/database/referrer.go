@@ -0,0 +1,75 @@
+package database
+
+import (
+	"github.com/webbleen/go-gin/models/response"
+	"github.com/webbleen/go-gin/pkg/referrer"
+	"gorm.io/gorm"
+)
+
+// visitQuery 访问记录查询的公共过滤条件；只覆盖保留期内的原始访问记录
+func visitQuery(startDate, endDate, language string, includeBots bool) *gorm.DB {
+	query := DB.Model(&VisitRecord{}).Scopes(withBots(includeBots), withLanguage(language))
+	if startDate != "" {
+		query = query.Where(dateExpr("created_on")+" >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where(dateExpr("created_on")+" <= ?", endDate)
+	}
+	return query
+}
+
+// GetReferrerStats 按渠道和来源域名统计访问量
+// 升级前的记录没有渠道信息，不参与统计
+func GetReferrerStats(limit int, startDate, endDate, language string, includeBots bool) (*response.ReferrerStatsResult, error) {
+	if err := checkRetained(startDate); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	res := &response.ReferrerStatsResult{
+		Channels:  make([]response.ChannelStat, 0),
+		Referrers: make([]response.ReferrerStat, 0, limit),
+	}
+	err := visitQuery(startDate, endDate, language, includeBots).
+		Where("channel != ''").
+		Select("channel, COUNT(*) as count").
+		Group("channel").
+		Order("count DESC").
+		Scan(&res.Channels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 站内跳转和直接访问没有外部来源域名
+	err = visitQuery(startDate, endDate, language, includeBots).
+		Where("referrer_domain != '' AND channel NOT IN ?", []string{"", referrer.ChannelInternal, referrer.ChannelDirect}).
+		Select("referrer_domain as domain, channel, COUNT(*) as count").
+		Group("referrer_domain, channel").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&res.Referrers).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetCampaignStats 按 utm_source / utm_medium / utm_campaign 统计访问量和会话数
+func GetCampaignStats(limit int, startDate, endDate, language string, includeBots bool) ([]response.CampaignStat, error) {
+	if err := checkRetained(startDate); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	stats := make([]response.CampaignStat, 0, limit)
+	err := visitQuery(startDate, endDate, language, includeBots).
+		Where("utm_source != '' OR utm_campaign != ''").
+		Select("utm_source as source, utm_medium as medium, utm_campaign as campaign, COUNT(*) as visits, COUNT(DISTINCT session_id) as sessions").
+		Group("utm_source, utm_medium, utm_campaign").
+		Order("visits DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
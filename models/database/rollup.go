@@ -0,0 +1,167 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VisitRollup 按天、页面、语言、国家、设备汇总的访问量和阅读数据（含义同 engagementRow）
+type VisitRollup struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Date      string `gorm:"size:10;index" json:"date"`
+	Page      string `gorm:"size:200" json:"page"`
+	Language  string `gorm:"size:10" json:"language"`
+	Country   string `gorm:"size:50" json:"country"`
+	Device    string `gorm:"size:50" json:"device"`
+	IsBot     bool   `json:"is_bot"`
+	Visits    int    `json:"visits"`
+	Engaged   int    `json:"engaged"`
+	Duration  int    `json:"duration"`
+	Completed int    `json:"completed"`
+	Bounces   int    `json:"bounces"`
+}
+
+// VisitRecordArchive 汇总后归档的原始访问记录，结构与 VisitRecord 相同
+type VisitRecordArchive struct {
+	VisitRecord
+}
+
+// 汇总后对原始记录的处理方式
+const (
+	RetentionDelete  = "delete"
+	RetentionArchive = "archive"
+)
+
+// rawRetentionDays 原始访问记录的保留天数，由 StartRetention 设置，0 表示永久保留
+// 汇总只保留 visit_rollup 中的页面、语言、国家、设备维度，以下报表读取原始访问记录，
+// 只覆盖保留期：用户行为（浏览器、操作系统）、机器人、来源、推广活动、搜索关键词、会话、漏斗和路径
+var rawRetentionDays int
+
+// ErrBeforeRetention 开始日期早于原始访问记录的保留期，这些日期的原始记录已汇总删除
+var ErrBeforeRetention = errors.New("start_date is earlier than the raw visit record retention period")
+
+// checkRetained 校验读取原始访问记录的开始日期不早于保留期，未指定开始日期时不限制
+func checkRetained(startDate string) error {
+	if startDate != "" && rawRetentionDays > 0 && startDate < recentStart(rawRetentionDays) {
+		return ErrBeforeRetention
+	}
+	return nil
+}
+
+// retainedDays 把读取原始访问记录的最近天数限制在保留期内
+func retainedDays(days int) int {
+	if rawRetentionDays > 0 && days > rawRetentionDays {
+		return rawRetentionDays
+	}
+	return days
+}
+
+// StartRetention 后台定时把超过 retentionDays 天的原始访问记录按页面汇总到 visit_rollup，
+// 然后按 action 删除或归档原始记录；retentionDays <= 0 时不启动
+func StartRetention(retentionDays int, interval time.Duration, action string) {
+	if retentionDays <= 0 || interval <= 0 {
+		return
+	}
+	rawRetentionDays = retentionDays
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if DB != nil {
+				if days, err := RollupVisits(retentionDays, action); err != nil {
+					log.Printf("访问记录汇总失败: %v", err)
+				} else if days > 0 {
+					log.Printf("已汇总 %d 天的访问记录 (%s)", days, action)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RollupVisits 汇总保留期（含今天共 retentionDays 天）之前的原始访问记录，返回处理的天数
+// 每天在一个事务中完成汇总和删除/归档
+func RollupVisits(retentionDays int, action string) (int, error) {
+	if retentionDays < 1 {
+		retentionDays = 1
+	}
+	cutoff := recentStart(retentionDays)
+
+	var dates []string
+	err := DB.Model(&VisitRecord{}).
+		Where(dateExpr("created_on")+" < ?", cutoff).
+		Distinct(dateExpr("created_on")).
+		Order(dateExpr("created_on")).
+		Pluck(dateExpr("created_on"), &dates).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i, date := range dates {
+		if err := DB.Transaction(func(tx *gorm.DB) error {
+			return rollupDate(tx, date, action)
+		}); err != nil {
+			return i, err
+		}
+	}
+	return len(dates), nil
+}
+
+// rollupDate 汇总某一天的原始记录并删除/归档
+func rollupDate(tx *gorm.DB, date string, action string) error {
+	raw := func() *gorm.DB {
+		return tx.Model(&VisitRecord{}).Where(dateExpr("created_on")+" = ?", date)
+	}
+
+	// daily_stats 在写入时已增量维护，这里只需汇总热门页面和阅读数据所需的维度
+	sameDay := func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(dateExpr("created_on")+" = ?", date)
+	}
+	var rollups []VisitRollup
+	err := withSessionPages(tx, sameDay).
+		Select("page, language, country, device, is_bot, " + engagementSelect()).
+		Group("page, language, country, device, is_bot").
+		Scan(&rollups).Error
+	if err != nil {
+		return err
+	}
+	for i := range rollups {
+		rollups[i].Date = date
+	}
+
+	if len(rollups) > 0 {
+		if err := tx.CreateInBatches(rollups, 200).Error; err != nil {
+			return err
+		}
+	}
+
+	if action == RetentionArchive {
+		columns, err := visitRecordColumns(tx)
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("INSERT INTO visit_record_archive ("+columns+") SELECT "+columns+
+			" FROM visit_record WHERE "+dateExpr("created_on")+" = ?", date).Error
+		if err != nil {
+			return err
+		}
+	}
+	// 当天已不再写入原始记录，去重用的访客登记一并删除
+	if err := tx.Where("date = ?", date).Delete(&DailyVisitor{}).Error; err != nil {
+		return err
+	}
+	return raw().Delete(&VisitRecord{}).Error
+}
+
+// visitRecordColumns 访问记录表的列名列表，归档时显式列出，避免两张表列顺序不同
+func visitRecordColumns(tx *gorm.DB) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(&VisitRecord{}); err != nil {
+		return "", err
+	}
+	return strings.Join(stmt.Schema.DBNames, ", "), nil
+}
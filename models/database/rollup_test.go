@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRetentionLimitsRawReports(t *testing.T) {
+	setupTestDB(t)
+	rawRetentionDays = 7
+	t.Cleanup(func() { rawRetentionDays = 0 })
+
+	first, before := recentStart(7), recentStart(8)
+	for _, start := range []string{"", first} {
+		if err := checkRetained(start); err != nil {
+			t.Errorf("checkRetained(%q) = %v", start, err)
+		}
+	}
+	if got := retainedDays(30); got != 7 {
+		t.Errorf("retainedDays(30) = %d, want 7", got)
+	}
+	if got := retainedDays(3); got != 3 {
+		t.Errorf("retainedDays(3) = %d, want 3", got)
+	}
+
+	reports := map[string]func(start string) error{
+		"referrers": func(start string) error { _, err := GetReferrerStats(10, start, "", "", false); return err },
+		"campaigns": func(start string) error { _, err := GetCampaignStats(10, start, "", "", false); return err },
+		"search":    func(start string) error { _, err := GetSearchTerms("", 10, start, "", "", false); return err },
+		"sessions":  func(start string) error { _, err := GetSessionStats(10, start, "", "", false); return err },
+		"funnel":    func(start string) error { _, err := GetFunnel([]string{"/"}, start, "", "", false); return err },
+	}
+	for name, report := range reports {
+		if err := report(before); !errors.Is(err, ErrBeforeRetention) {
+			t.Errorf("%s with start %s: error = %v, want ErrBeforeRetention", name, before, err)
+		}
+		for _, start := range []string{"", first} {
+			if err := report(start); err != nil {
+				t.Errorf("%s with start %q: %v", name, start, err)
+			}
+		}
+	}
+
+	res, err := GetBotStats(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Days != 7 || len(res.Points) != 7 {
+		t.Errorf("bot stats cover %d days with %d points, want 7", res.Days, len(res.Points))
+	}
+}
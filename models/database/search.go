@@ -0,0 +1,108 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// 搜索关键词来源
+const (
+	SearchTypeEngine = "engine" // 搜索引擎来源 URL
+	SearchTypeSite   = "site"   // 站内搜索页面 URL
+)
+
+// SearchEvent 站内搜索事件名称，properties 中 term 为关键词、results 为结果数
+const SearchEvent = "search"
+
+// ErrInvalidSearchType 搜索关键词来源不合法
+var ErrInvalidSearchType = errors.New("search type must be engine or site")
+
+func validSearchType(searchType string) bool {
+	return searchType == "" || searchType == SearchTypeEngine || searchType == SearchTypeSite
+}
+
+// GetSearchTerms 按次数降序返回搜索关键词，searchType 为空时同时返回两种来源
+func GetSearchTerms(searchType string, limit int, startDate, endDate, language string, includeBots bool) ([]response.SearchTermStat, error) {
+	if !validSearchType(searchType) {
+		return nil, ErrInvalidSearchType
+	}
+	if err := checkRetained(startDate); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	query := visitQuery(startDate, endDate, language, includeBots).Where("search_term != ''")
+	if searchType != "" {
+		query = query.Where("search_type = ?", searchType)
+	}
+	stats := make([]response.SearchTermStat, 0, limit)
+	err := query.
+		Select("search_term as term, search_type as type, COUNT(*) as count, COUNT(DISTINCT session_id) as sessions").
+		Group("search_term, search_type").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetZeroResultSearches 按次数降序返回没有结果的站内搜索关键词
+// 来自前端上报的 search 事件，properties.results 为 0 时视为无结果
+func GetZeroResultSearches(limit int, startDate, endDate, language string, includeBots bool) ([]response.ZeroResultSearch, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	term := jsonFieldExpr("properties", "term")
+	stats := make([]response.ZeroResultSearch, 0, limit)
+	err := eventQuery(startDate, endDate, language, includeBots).
+		Where("name = ?", SearchEvent).
+		Where(jsonFieldExpr("properties", "results")+" = ?", "0").
+		Where(term + " != ''").
+		Select(term + " as term, COUNT(*) as count, COUNT(DISTINCT session_id) as sessions").
+		Group(term).
+		Order("count DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetSearchTrend 单个搜索关键词最近N天每天的次数，term 需已规范化；天数不超过原始访问记录的保留期
+func GetSearchTrend(term, searchType string, days int, language string, includeBots bool) (*response.SearchTrendResult, error) {
+	if !validSearchType(searchType) {
+		return nil, ErrInvalidSearchType
+	}
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	days = retainedDays(days)
+	start := recentStart(days)
+	query := visitQuery(start, "", language, includeBots).Where("search_term = ?", term)
+	if searchType != "" {
+		query = query.Where("search_type = ?", searchType)
+	}
+
+	var points []response.SearchTrendPoint
+	err := query.
+		Select(dateExpr("created_on") + " as date, COUNT(*) as count").
+		Group(dateExpr("created_on")).
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := &response.SearchTrendResult{Term: term, Type: searchType}
+	byDate := make(map[string]int, len(points))
+	for _, p := range points {
+		byDate[p.Date] = p.Count
+		res.Total += p.Count
+	}
+	startTime, _ := time.Parse("2006-01-02", start)
+	res.Points = make([]response.SearchTrendPoint, 0, days)
+	for i := 0; i < days; i++ {
+		d := startTime.AddDate(0, 0, i).Format("2006-01-02")
+		res.Points = append(res.Points, response.SearchTrendPoint{Date: d, Count: byDate[d]})
+	}
+	return res, nil
+}
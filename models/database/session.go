@@ -0,0 +1,214 @@
+package database
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// SessionTimeout 同一 session_id 两次访问间隔超过该时长时视为新会话
+const SessionTimeout = 30 * time.Minute
+
+// 会话分析的日期范围：未指定开始日期时分析最近 sessionDefaultDays 天，最多 sessionMaxDays 天
+// 会话重建需要把范围内的原始记录逐行读入内存，因此限制范围
+const (
+	sessionDefaultDays = 30
+	sessionMaxDays     = 90
+)
+
+// ErrSessionRange 会话分析的日期格式不合法或范围超过 sessionMaxDays 天
+var ErrSessionRange = errors.New("start_date and end_date must be YYYY-MM-DD and span at most 90 days")
+
+// sessionRange 校验会话分析的日期范围，未指定结束日期时到今天为止
+func sessionRange(startDate, endDate string) (string, string, error) {
+	if startDate == "" {
+		startDate = recentStart(retainedDays(sessionDefaultDays))
+	}
+	if endDate == "" {
+		endDate = today()
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return "", "", ErrSessionRange
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil || end.Before(start) || end.After(start.AddDate(0, 0, sessionMaxDays-1)) {
+		return "", "", ErrSessionRange
+	}
+	if err := checkRetained(startDate); err != nil {
+		return "", "", err
+	}
+	return startDate, endDate, nil
+}
+
+// session 按时间顺序排列的一次会话的访问
+type session struct {
+	pages []string
+	first time.Time
+	last  time.Time
+	tail  int // 最后一个页面上报的停留秒数
+}
+
+// duration 会话时长：首末两次访问的间隔加上最后一个页面的停留时长
+func (s *session) duration() time.Duration {
+	return s.last.Sub(s.first) + time.Duration(s.tail)*time.Second
+}
+
+// sessionStats 会话分析的累加器
+type sessionStats struct {
+	sessions    int
+	pages       int
+	bounces     int
+	duration    time.Duration
+	entries     map[string]int
+	exits       map[string]int
+	transitions map[[2]string]int
+}
+
+func newSessionStats() *sessionStats {
+	return &sessionStats{
+		entries:     make(map[string]int),
+		exits:       make(map[string]int),
+		transitions: make(map[[2]string]int),
+	}
+}
+
+func (st *sessionStats) add(s *session) {
+	st.sessions++
+	st.pages += len(s.pages)
+	st.duration += s.duration()
+	if len(s.pages) == 1 {
+		st.bounces++
+	}
+	st.entries[s.pages[0]]++
+	st.exits[s.pages[len(s.pages)-1]]++
+	for i := 1; i < len(s.pages); i++ {
+		st.transitions[[2]string{s.pages[i-1], s.pages[i]}]++
+	}
+}
+
+// reconstructSessions 按 session_id 和访问时间顺序读取原始访问记录，
+// 间隔超过 SessionTimeout 时拆分为多个会话，每个会话调用一次 fn
+func reconstructSessions(startDate, endDate, language string, includeBots bool, fn func(*session)) error {
+	startDate, endDate, err := sessionRange(startDate, endDate)
+	if err != nil {
+		return err
+	}
+	query := DB.Model(&VisitRecord{}).
+		Select("id, session_id, page, created_on, duration").
+		Scopes(withBots(includeBots), withLanguage(language)).
+		Where("session_id <> ''").
+		Where(dateExpr("created_on")+" >= ? AND "+dateExpr("created_on")+" <= ?", startDate, endDate)
+
+	// FindInBatches 只能按主键分页，这里逐行读取以保持会话内的时间顺序
+	rows, err := query.Order("session_id, created_on, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *session
+	var currentID string
+	for rows.Next() {
+		var r VisitRecord
+		if err := DB.ScanRows(rows, &r); err != nil {
+			return err
+		}
+		if current != nil && (r.SessionID != currentID || r.CreatedOn.Sub(current.last) > SessionTimeout) {
+			fn(current)
+			current = nil
+		}
+		if current == nil {
+			current = &session{first: r.CreatedOn}
+			currentID = r.SessionID
+		}
+		current.pages = append(current.pages, r.Page)
+		current.last = r.CreatedOn
+		current.tail = r.Duration
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		fn(current)
+	}
+	return nil
+}
+
+// GetSessionStats 会话概览：会话数、平均时长、平均页数、跳出率，以及入口页和退出页
+// 只覆盖保留期内的原始访问记录，未指定开始日期时分析最近 30 天
+func GetSessionStats(limit int, startDate, endDate, language string, includeBots bool) (*response.SessionStatsResult, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	st := newSessionStats()
+	if err := reconstructSessions(startDate, endDate, language, includeBots, st.add); err != nil {
+		return nil, err
+	}
+
+	res := &response.SessionStatsResult{
+		Sessions:   st.sessions,
+		EntryPages: topPageStats(st.entries, limit),
+		ExitPages:  topPageStats(st.exits, limit),
+	}
+	if st.sessions > 0 {
+		res.AvgDuration = st.duration.Seconds() / float64(st.sessions)
+		res.AvgPages = float64(st.pages) / float64(st.sessions)
+		res.BounceRate = float64(st.bounces) / float64(st.sessions)
+	}
+	return res, nil
+}
+
+// GetSessionTransitions 会话内最常见的页面跳转；from 不为空时只返回从该页面出发的跳转
+func GetSessionTransitions(from string, limit int, startDate, endDate, language string, includeBots bool) ([]response.PageTransition, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	from = ParseURL(from)
+	st := newSessionStats()
+	if err := reconstructSessions(startDate, endDate, language, includeBots, st.add); err != nil {
+		return nil, err
+	}
+
+	transitions := make([]response.PageTransition, 0)
+	for t, count := range st.transitions {
+		if from != "" && t[0] != from {
+			continue
+		}
+		transitions = append(transitions, response.PageTransition{From: t[0], To: t[1], Count: count})
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		a, b := transitions[i], transitions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	if len(transitions) > limit {
+		transitions = transitions[:limit]
+	}
+	return transitions, nil
+}
+
+// topPageStats 按次数降序取前 limit 个页面
+func topPageStats(counts map[string]int, limit int) []response.PageStat {
+	stats := make([]response.PageStat, 0, len(counts))
+	for page, count := range counts {
+		stats = append(stats, response.PageStat{Page: page, Count: count})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Page < stats[j].Page
+	})
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// pageVisit 构造一条指定页面的访问记录
+func pageVisit(clock, session, page string) *VisitRecord {
+	v := visitAt("2024-03-04", clock, "1.1.1.1", session, "zh", false)
+	v.Page = page
+	return v
+}
+
+func TestSessionSplitAtTimeout(t *testing.T) {
+	setupTestDB(t)
+
+	last := pageVisit("10:45", "s1", "/c")
+	last.Duration = 60
+	addVisits(t, []*VisitRecord{
+		pageVisit("10:00", "s1", "/a"),
+		pageVisit("10:10", "s1", "/b"),
+		// 距上次访问 35 分钟，拆分为新会话
+		last,
+		pageVisit("11:00", "s2", "/a"),
+		// 恰好 30 分钟，仍属于同一会话
+		pageVisit("11:30", "s2", "/b"),
+	})
+
+	res, err := GetSessionStats(10, "2024-03-04", "2024-03-04", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &response.SessionStatsResult{
+		Sessions:    3,
+		AvgDuration: (600 + 60 + 1800) / 3.0,
+		AvgPages:    5 / 3.0,
+		BounceRate:  1 / 3.0,
+		EntryPages:  []response.PageStat{{Page: "/a", Count: 2}, {Page: "/c", Count: 1}},
+		ExitPages:   []response.PageStat{{Page: "/b", Count: 2}, {Page: "/c", Count: 1}},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("GetSessionStats = %+v, want %+v", res, want)
+	}
+
+	transitions, err := GetSessionTransitions("", 10, "2024-03-04", "2024-03-04", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []response.PageTransition{{From: "/a", To: "/b", Count: 2}}; !reflect.DeepEqual(transitions, want) {
+		t.Errorf("GetSessionTransitions = %+v, want %+v", transitions, want)
+	}
+
+	// 日期范围之外没有会话
+	res, err = GetSessionStats(10, "2024-03-05", "2024-03-05", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sessions != 0 || res.BounceRate != 0 {
+		t.Errorf("empty range = %+v", res)
+	}
+}
+
+func TestSessionRange(t *testing.T) {
+	tests := []struct {
+		start, end string
+		ok         bool
+	}{
+		{"2024-01-01", "2024-03-30", true}, // 恰好 90 天
+		{"2024-01-01", "2024-03-31", false},
+		{"2024-03-04", "2024-03-04", true},
+		{"2024-03-05", "2024-03-04", false},
+		{"2024-3-4", "2024-03-04", false},
+		{"2024-03-04", "today", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		_, _, err := sessionRange(tt.start, tt.end)
+		if (err == nil) != tt.ok {
+			t.Errorf("sessionRange(%q, %q) error = %v, want ok = %v", tt.start, tt.end, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrSessionRange) {
+			t.Errorf("sessionRange(%q, %q) error = %v, want ErrSessionRange", tt.start, tt.end, err)
+		}
+	}
+}
@@ -2,9 +2,13 @@ package database
 
 import (
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/webbleen/go-gin/models/response"
+	"github.com/webbleen/go-gin/pkg/privacy"
 	"gorm.io/gorm"
 )
 
@@ -29,6 +33,31 @@ type VisitRecord struct {
 	Browser   string `json:"browser" gorm:"size:50"`
 	OS        string `json:"os" gorm:"size:50"`
 	Language  string `json:"language" gorm:"size:10"`
+
+	BrowserVersion string `json:"browser_version" gorm:"size:50"`
+	OSVersion      string `json:"os_version" gorm:"size:50"`
+
+	// 机器人标记由服务端判定，前端传入的值会被覆盖
+	IsBot   bool   `json:"is_bot" gorm:"default:false;index"`
+	BotName string `json:"bot_name" gorm:"size:50"`
+
+	// 来源与推广活动，由服务端根据 referer 和页面 URL 中的 utm_* 参数解析
+	ReferrerDomain string `json:"referrer_domain" gorm:"size:100"`
+	Channel        string `json:"channel" gorm:"size:20;index"`
+	UTMSource      string `json:"utm_source" gorm:"column:utm_source;size:100"`
+	UTMMedium      string `json:"utm_medium" gorm:"column:utm_medium;size:100"`
+	UTMCampaign    string `json:"utm_campaign" gorm:"column:utm_campaign;size:100"`
+
+	// 搜索关键词，来自搜索引擎来源 URL（engine）或站内搜索页面 URL（site），已规范化
+	SearchTerm string `json:"search_term" gorm:"size:100;index"`
+	SearchType string `json:"search_type" gorm:"size:10"`
+
+	// 阅读数据，由 /stats/engagement 上报后更新
+	Duration    int `json:"duration" gorm:"default:0"`     // 停留秒数
+	ScrollDepth int `json:"scroll_depth" gorm:"default:0"` // 最大滚动深度（0-100）
+
+	// Webdriver 前端上报的 navigator.webdriver，仅用于机器人判定，不入库
+	Webdriver bool `json:"webdriver" gorm:"-"`
 }
 
 // ContentStats 内容统计表
@@ -40,26 +69,121 @@ type ContentStats struct {
 	LastUpdate      time.Time `json:"last_update"`
 }
 
-// 访问记录相关方法
-func AddVisitRecord(record *VisitRecord) bool {
+// PrepareVisitRecord 存储前的规范化：记录访问时间、解析 URL、按隐私模式处理 IP
+// 每条记录只能调用一次，异步写入时在入队前调用
+func PrepareVisitRecord(record *VisitRecord) {
+	// ID 和访问时间由服务端决定，忽略前端传入的值
+	record.ID = 0
+	record.CreatedOn = time.Now()
+	// 阅读数据只通过 UpdateEngagement 更新
+	record.Duration = 0
+	record.ScrollDepth = 0
 	// 在存储前解析URL，将编码的路径转换为可读格式
-	record.Page = ParseURL(record.Page)
-	DB.Create(record)
-	return true
+	record.Page = NormalizePage(record.Page)
+	// 按隐私模式处理 IP（截断、哈希或不保存），哈希的盐值按报表时区的日期轮换，与独立访客的统计日期一致
+	record.IP = privacy.AnonymizeIP(record.IP, record.CreatedOn.In(reportLoc))
+	// 截断到字段长度，PostgreSQL 中一条超长的记录会使整批写入失败
+	record.SessionID = clip(record.SessionID, 100)
+	record.UserAgent = clip(record.UserAgent, 500)
+	record.Referer = clip(record.Referer, 500)
+	record.Language = clip(record.Language, 10)
+	// allLanguages 保留给 daily_stats 的跨语言汇总行
+	if record.Language == allLanguages {
+		record.Language = ""
+	}
+	for _, f := range []*string{&record.Country, &record.City, &record.Device, &record.Browser, &record.OS,
+		&record.BrowserVersion, &record.OSVersion, &record.BotName} {
+		*f = clip(*f, 50)
+	}
+	for _, f := range []*string{&record.ReferrerDomain, &record.UTMSource, &record.UTMMedium, &record.UTMCampaign, &record.SearchTerm} {
+		*f = clip(*f, 100)
+	}
+	record.Channel = clip(record.Channel, 20)
+	record.SearchType = clip(record.SearchType, 10)
+}
+
+// AddVisitRecord 同步写入一条访问记录
+func AddVisitRecord(record *VisitRecord) error {
+	PrepareVisitRecord(record)
+	return AddVisitRecords([]*VisitRecord{record})
+}
+
+// AddVisitRecords 在一个事务中批量写入已经过 PrepareVisitRecord 处理的访问记录，并更新 daily_stats
+func AddVisitRecords(records []*VisitRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(records, 200).Error; err != nil {
+			return err
+		}
+		return incrementDailyStats(tx, records)
+	})
+	if err != nil {
+		// 事务已回滚，清除插入时回填的 ID，便于调用方重试
+		for _, record := range records {
+			record.ID = 0
+		}
+	}
+	return err
+}
+
+// VisitedPage 今日已记录的会话与页面，用于访问去重
+type VisitedPage struct {
+	SessionID string
+	Page      string
+}
+
+// GetVisitedPagesToday 今日已记录的全部会话与页面
+func GetVisitedPagesToday() ([]VisitedPage, error) {
+	var pages []VisitedPage
+	err := DB.Model(&VisitRecord{}).
+		Distinct("session_id", "page").
+		Where(dateExpr("created_on")+" = ?", today()).
+		Scan(&pages).Error
+	return pages, err
 }
 
 // CheckVisitExists 检查今日是否已记录过该页面的访问
 func CheckVisitExists(sessionID, page string) bool {
-	today := time.Now().Format("2006-01-02")
 	// 解析URL，确保比较的是解析后的格式
-	parsedPage := ParseURL(page)
+	parsedPage := NormalizePage(page)
 	var count int64
 	DB.Model(&VisitRecord{}).
-		Where("session_id = ? AND page = ? AND DATE(created_on) = ?", sessionID, parsedPage, today).
+		Where("session_id = ? AND page = ? AND "+dateExpr("created_on")+" = ?", sessionID, parsedPage, today()).
 		Count(&count)
 	return int(count) > 0
 }
 
+// pageMaxLength 访问记录、事件中页面字段的长度
+const pageMaxLength = 200
+
+// NormalizePage 存储和去重使用的页面：解析后的路径截断到字段长度
+func NormalizePage(page string) string {
+	return clip(ParseURL(page), pageMaxLength)
+}
+
+// clip 按字符截断字符串，PostgreSQL 的 varchar(n) 按字符计算长度
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "")
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i, count := 0, 0
+	for i = range s {
+		if count == n {
+			break
+		}
+		count++
+	}
+	return s[:i]
+}
+
 // ParseURL 解析URL，将编码的路径转换为可读格式
 func ParseURL(rawURL string) string {
 	// 如果URL为空或只是斜杠，返回原值
@@ -98,95 +222,69 @@ func ParseURL(rawURL string) string {
 	return decodedPath
 }
 
-func GetTodayVisits(language string) int {
-	var count int64
-	today := time.Now().Format("2006-01-02")
-	query := DB.Model(&VisitRecord{}).Where("DATE(created_on) = ?", today)
-	if language != "" {
-		query = query.Where("language = ?", language)
-	} else {
-		// 当没有指定语言时，只统计有语言信息的记录
-		query = query.Where("language IS NOT NULL AND language != ''")
+// GetTodayVisits 获取 loc 时区今天的访问量
+func GetTodayVisits(language string, includeBots bool, loc *time.Location) int {
+	if !isReportLocation(loc) {
+		return countToday("COUNT(*)", loc, language, includeBots)
 	}
 	// 统计所有页面访问（不按session_id去重，每个页面访问都算一次）
-	query.Count(&count)
-	return int(count)
+	return sumDailyStats("visits", onDate(today()), withBots(includeBots), withDailyLanguage(language, "visits"))
 }
 
-func GetTotalVisits(language string) int {
-	var count int64
-	query := DB.Model(&VisitRecord{})
-	if language != "" {
-		query = query.Where("language = ?", language)
-	} else {
-		// 当没有指定语言时，只统计有语言信息的记录
-		query = query.Where("language IS NOT NULL AND language != ''")
-	}
-	// 统计所有页面访问（不按session_id去重，每个页面访问都算一次）
-	query.Count(&count)
-	return int(count)
+func GetTotalVisits(language string, includeBots bool) int {
+	return sumDailyStats("visits", withBots(includeBots), withDailyLanguage(language, "visits"))
 }
 
-func GetUniqueVisitorsToday(language string) int {
-	var count int64
-	today := time.Now().Format("2006-01-02")
-	query := DB.Model(&VisitRecord{}).Where("DATE(created_on) = ?", today)
-	if language != "" {
-		query = query.Where("language = ?", language)
-	} else {
-		// 当没有指定语言时，只统计有语言信息的记录
-		query = query.Where("language IS NOT NULL AND language != ''")
+// GetUniqueVisitorsToday 获取 loc 时区今天的独立访客数
+func GetUniqueVisitorsToday(language string, includeBots bool, loc *time.Location) int {
+	if !isReportLocation(loc) {
+		return countToday("COUNT(DISTINCT "+visitorExpr()+")", loc, language, includeBots)
 	}
-	query.Group("ip").Count(&count)
-	return int(count)
+	return sumDailyStats("unique_visitors", onDate(today()), withBots(includeBots), withDailyLanguage(language, "unique_visitors"))
 }
 
 // GetTodayUniqueSessions 获取今日独立会话数（按session_id去重）
-func GetTodayUniqueSessions(language string) int {
-	var count int64
-	today := time.Now().Format("2006-01-02")
-	query := DB.Model(&VisitRecord{}).Where("DATE(created_on) = ?", today)
-	if language != "" {
-		query = query.Where("language = ?", language)
-	} else {
-		// 当没有指定语言时，只统计有语言信息的记录
-		query = query.Where("language IS NOT NULL AND language != ''")
+func GetTodayUniqueSessions(language string, includeBots bool, loc *time.Location) int {
+	if !isReportLocation(loc) {
+		return countToday("COUNT(DISTINCT session_id)", loc, language, includeBots)
 	}
-	query.Group("session_id").Count(&count)
-	return int(count)
+	return sumDailyStats("unique_sessions", onDate(today()), withBots(includeBots), withDailyLanguage(language, "unique_sessions"))
 }
 
-// GetTotalUniqueSessions 获取总独立会话数（按session_id去重）
-func GetTotalUniqueSessions(language string) int {
-	var count int64
-	query := DB.Model(&VisitRecord{})
-	if language != "" {
-		query = query.Where("language = ?", language)
-	} else {
-		// 当没有指定语言时，只统计有语言信息的记录
-		query = query.Where("language IS NOT NULL AND language != ''")
-	}
-	query.Group("session_id").Count(&count)
-	return int(count)
+// countToday 按 loc 时区的今天统计原始访问记录
+// daily_stats 按报表时区划分日期，其他时区的请求只能读取原始记录
+func countToday(expr string, loc *time.Location, language string, includeBots bool) int {
+	var n int64
+	DB.Model(&VisitRecord{}).
+		Scopes(withBots(includeBots), withKnownLanguage(language)).
+		Where(dateExprIn("created_on", loc)+" = ?", todayIn(loc)).
+		Select(expr).
+		Scan(&n)
+	return int(n)
+}
+
+// GetTotalUniqueSessions 获取总独立会话数（按天按session_id去重后累加，跨天的会话会被重复计入）
+func GetTotalUniqueSessions(language string, includeBots bool) int {
+	return sumDailyStats("unique_sessions", withBots(includeBots), withDailyLanguage(language, "unique_sessions"))
 }
 
 // 用户行为分析
-func GetUserBehaviorStats() *response.UserBehaviorResult {
+func GetUserBehaviorStats(includeBots bool) *response.UserBehaviorResult {
 	// 设备统计
 	var deviceStats []response.DeviceStat
-	DB.Model(&VisitRecord{}).Select("device, count(*) as count").Group("device").Find(&deviceStats)
+	DB.Model(&VisitRecord{}).Scopes(withBots(includeBots)).Select("device, count(*) as count").Group("device").Find(&deviceStats)
 
 	// 浏览器统计
 	var browserStats []response.BrowserStat
-	DB.Model(&VisitRecord{}).Select("browser, count(*) as count").Group("browser").Find(&browserStats)
+	DB.Model(&VisitRecord{}).Scopes(withBots(includeBots)).Select("browser, count(*) as count").Group("browser").Find(&browserStats)
 
 	// 操作系统统计
 	var osStats []response.OSStat
-	DB.Model(&VisitRecord{}).Select("os, count(*) as count").Group("os").Find(&osStats)
+	DB.Model(&VisitRecord{}).Scopes(withBots(includeBots)).Select("os, count(*) as count").Group("os").Find(&osStats)
 
 	// 地理位置统计
 	var locationStats []response.LocationStat
-	DB.Model(&VisitRecord{}).Select("country, city, count(*) as count").Group("country, city").Order("count DESC").Limit(10).Find(&locationStats)
+	DB.Model(&VisitRecord{}).Scopes(withBots(includeBots)).Select("country, city, count(*) as count").Group("country, city").Order("count DESC").Limit(10).Find(&locationStats)
 
 	return &response.UserBehaviorResult{
 		Devices:          deviceStats,
@@ -197,129 +295,84 @@ func GetUserBehaviorStats() *response.UserBehaviorResult {
 }
 
 // 热门页面统计（可限制数量）
-func GetTopPages(limit int, startDate, endDate string, language string) ([]response.PageStat, error) {
+func GetTopPages(limit int, startDate, endDate string, language string, includeBots bool) ([]response.PageStat, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 10
 	}
 
-	query := DB.Model(&VisitRecord{})
+	query := DB.Model(&VisitRecord{}).Scopes(withBots(includeBots))
 	if startDate != "" {
-		query = query.Where("DATE(created_on) >= ?", startDate)
+		query = query.Where(dateExpr("created_on")+" >= ?", startDate)
 	}
 	if endDate != "" {
-		query = query.Where("DATE(created_on) <= ?", endDate)
+		query = query.Where(dateExpr("created_on")+" <= ?", endDate)
 	}
 	if language != "" {
 		query = query.Where("language = ?", language)
 	}
 
-	type row struct {
-		Page  string
-		Count int
+	// 合并已汇总的历史访问量，合并后再排序取前 limit 个
+	rollupQuery := DB.Model(&VisitRollup{}).Scopes(withBots(includeBots), withLanguage(language))
+	if startDate != "" {
+		rollupQuery = rollupQuery.Where("date >= ?", startDate)
 	}
-	var rows []row
-	err := query.Select("page, count(*) as count").
+	if endDate != "" {
+		rollupQuery = rollupQuery.Where("date <= ?", endDate)
+	}
+
+	var stats []response.PageStat
+	err := unionAll(
+		query.Select("page, COUNT(*) as count").Group("page"),
+		rollupQuery.Select("page, SUM(visits) as count").Group("page"),
+	).
+		Select("page, SUM(count) as count").
 		Group("page").
-		Order("count DESC").
+		Order("count DESC, page").
 		Limit(limit).
-		Scan(&rows).Error
+		Scan(&stats).Error
 	if err != nil {
 		return nil, err
 	}
-
-	stats := make([]response.PageStat, 0, len(rows))
-	for _, r := range rows {
-		stats = append(stats, response.PageStat{Page: r.Page, Count: r.Count})
-	}
 	return stats, nil
 }
 
-// 趋势/日统计（按天聚合）
-func GetTrend(days int, language string) (*response.TrendResult, error) {
-	if days <= 0 || days > 365 {
-		days = 30
-	}
-	// 计算起始日期（含当天）
-	start := time.Now().AddDate(0, 0, -days+1).Format("2006-01-02")
-
-	type row struct {
-		Date  string
-		Count int
-	}
-
-	// 访问量（不去重）
-	query := DB.Model(&VisitRecord{}).Where("DATE(created_on) >= ?", start)
-	if language != "" {
-		query = query.Where("language = ?", language)
-	}
-	var visitRows []row
-	err := query.Select("DATE(created_on) as date, COUNT(*) as count").
-		Group("DATE(created_on)").
-		Order("date").
-		Scan(&visitRows).Error
-	if err != nil {
-		return nil, err
-	}
-
-	// 独立访客（按 IP 去重）
-	var uvRows []row
-	err = DB.Model(&VisitRecord{}).
-		Where("DATE(created_on) >= ?", start).
-		Scopes(withLanguage(language)).
-		Select("DATE(created_on) as date, COUNT(DISTINCT ip) as count").
-		Group("DATE(created_on)").
-		Order("date").
-		Scan(&uvRows).Error
-	if err != nil {
-		return nil, err
-	}
-
-	// 独立会话（按 session_id 去重）
-	var usRows []row
-	err = DB.Model(&VisitRecord{}).
-		Where("DATE(created_on) >= ?", start).
-		Scopes(withLanguage(language)).
-		Select("DATE(created_on) as date, COUNT(DISTINCT session_id) as count").
-		Group("DATE(created_on)").
-		Order("date").
-		Scan(&usRows).Error
-	if err != nil {
-		return nil, err
-	}
-
-	// 合并到完整连续的日期序列
-	visitMap := make(map[string]int)
-	for _, r := range visitRows {
-		visitMap[r.Date] = r.Count
-	}
-	uvMap := make(map[string]int)
-	for _, r := range uvRows {
-		uvMap[r.Date] = r.Count
-	}
-	usMap := make(map[string]int)
-	for _, r := range usRows {
-		usMap[r.Date] = r.Count
+// unionAll 把列相同的多个查询合并（UNION ALL）为一个子查询，用于先合并原始记录和汇总数据再排序分页
+func unionAll(queries ...*gorm.DB) *gorm.DB {
+	parts := make([]string, len(queries))
+	args := make([]interface{}, len(queries))
+	for i, q := range queries {
+		// SQLite 不支持括号包裹的 UNION 成员，因此每个子查询再包一层 SELECT
+		parts[i] = "SELECT * FROM (?) u" + strconv.Itoa(i)
+		args[i] = q
 	}
+	return DB.Table("("+strings.Join(parts, " UNION ALL ")+") u", args...)
+}
 
-	points := make([]response.TrendPoint, 0, days)
-	startTime, _ := time.Parse("2006-01-02", start)
-	for i := 0; i < days; i++ {
-		d := startTime.AddDate(0, 0, i).Format("2006-01-02")
-		points = append(points, response.TrendPoint{
-			Date:           d,
-			Visits:         visitMap[d],
-			UniqueVisitors: uvMap[d],
-			UniqueSessions: usMap[d],
-		})
+func withLanguage(language string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if language != "" {
+			return tx.Where("language = ?", language)
+		}
+		return tx
 	}
-	return &response.TrendResult{Points: points}, nil
 }
 
-func withLanguage(language string) func(*gorm.DB) *gorm.DB {
+// withKnownLanguage 指定语言时按语言过滤，否则只统计有语言信息的记录
+func withKnownLanguage(language string) func(*gorm.DB) *gorm.DB {
 	return func(tx *gorm.DB) *gorm.DB {
 		if language != "" {
 			return tx.Where("language = ?", language)
 		}
+		return tx.Where("language IS NOT NULL AND language != ''")
+	}
+}
+
+// withBots 未要求包含机器人流量时只统计真人访问
+func withBots(includeBots bool) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if !includeBots {
+			return tx.Where("is_bot = ?", false)
+		}
 		return tx
 	}
 }
@@ -354,8 +407,8 @@ func UpdateContentStats(articles, tags, categories int) error {
 	return DB.Create(&cs).Error
 }
 
-// 分页获取访问记录
-func GetVisitRecords(page, pageSize int, language string) (*response.VisitRecordsResult, error) {
+// 分页获取访问记录，时间按 loc 时区格式化
+func GetVisitRecords(page, pageSize int, language string, includeBots bool, loc *time.Location) (*response.VisitRecordsResult, error) {
 	// 限制每页最大数量
 	if pageSize > 100 {
 		pageSize = 100
@@ -365,7 +418,7 @@ func GetVisitRecords(page, pageSize int, language string) (*response.VisitRecord
 	offset := (page - 1) * pageSize
 
 	// 构建查询
-	query := DB.Model(&VisitRecord{})
+	query := DB.Model(&VisitRecord{}).Scopes(withBots(includeBots))
 
 	// 语言过滤
 	if language != "" {
@@ -391,20 +444,33 @@ func GetVisitRecords(page, pageSize int, language string) (*response.VisitRecord
 	var responseRecords []response.VisitRecord
 	for _, record := range records {
 		responseRecords = append(responseRecords, response.VisitRecord{
-			ID:         int(record.ID),
-			IP:         record.IP,
-			UserAgent:  record.UserAgent,
-			Referer:    record.Referer,
-			Page:       record.Page,
-			SessionID:  record.SessionID,
-			Country:    record.Country,
-			City:       record.City,
-			Device:     record.Device,
-			Browser:    record.Browser,
-			OS:         record.OS,
-			Language:   record.Language,
-			CreatedOn:  record.CreatedOn.Format("2006-01-02 15:04:05"),
-			ModifiedOn: record.ModifiedOn.Format("2006-01-02 15:04:05"),
+			ID:             int(record.ID),
+			IP:             record.IP,
+			UserAgent:      record.UserAgent,
+			Referer:        record.Referer,
+			Page:           record.Page,
+			SessionID:      record.SessionID,
+			Country:        record.Country,
+			City:           record.City,
+			Device:         record.Device,
+			Browser:        record.Browser,
+			OS:             record.OS,
+			Language:       record.Language,
+			BrowserVersion: record.BrowserVersion,
+			OSVersion:      record.OSVersion,
+			IsBot:          record.IsBot,
+			BotName:        record.BotName,
+			ReferrerDomain: record.ReferrerDomain,
+			Channel:        record.Channel,
+			UTMSource:      record.UTMSource,
+			UTMMedium:      record.UTMMedium,
+			UTMCampaign:    record.UTMCampaign,
+			SearchTerm:     record.SearchTerm,
+			SearchType:     record.SearchType,
+			Duration:       record.Duration,
+			ScrollDepth:    record.ScrollDepth,
+			CreatedOn:      record.CreatedOn.In(loc).Format("2006-01-02 15:04:05"),
+			ModifiedOn:     record.ModifiedOn.In(loc).Format("2006-01-02 15:04:05"),
 		})
 	}
 
@@ -423,63 +489,26 @@ func GetVisitRecords(page, pageSize int, language string) (*response.VisitRecord
 }
 
 // 获取访问统计概览
-func GetVisitOverview() (*response.VisitOverviewResult, error) {
+func GetVisitOverview(includeBots bool, loc *time.Location) (*response.VisitOverviewResult, error) {
 	// 今日访问量
-	todayVisits := GetTodayVisits("")
+	todayVisits := GetTodayVisits("", includeBots, loc)
 
 	// 累计访问量
-	totalVisits := GetTotalVisits("")
+	totalVisits := GetTotalVisits("", includeBots)
 
 	// 今日独立访客
-	uniqueVisitorsToday := GetUniqueVisitorsToday("")
+	uniqueVisitorsToday := GetUniqueVisitorsToday("", includeBots, loc)
 
 	// 今日独立会话数
-	todayUniqueSessions := GetTodayUniqueSessions("")
+	todayUniqueSessions := GetTodayUniqueSessions("", includeBots, loc)
 
 	// 总独立会话数
-	totalUniqueSessions := GetTotalUniqueSessions("")
-
-	// 按语言统计
-	languageStats := make(map[string]int64)
-	var languages []string
-	DB.Model(&VisitRecord{}).
-		Select("language").
-		Group("language").
-		Pluck("language", &languages)
-
-	for _, lang := range languages {
-		var count int64
-		DB.Model(&VisitRecord{}).Where("language = ?", lang).Count(&count)
-		languageStats[lang] = count
-	}
-
-	// 按设备统计
-	deviceStats := make(map[string]int64)
-	var devices []string
-	DB.Model(&VisitRecord{}).
-		Select("device").
-		Group("device").
-		Pluck("device", &devices)
-
-	for _, device := range devices {
-		var count int64
-		DB.Model(&VisitRecord{}).Where("device = ?", device).Count(&count)
-		deviceStats[device] = count
-	}
+	totalUniqueSessions := GetTotalUniqueSessions("", includeBots)
 
-	// 按国家统计
-	countryStats := make(map[string]int64)
-	var countries []string
-	DB.Model(&VisitRecord{}).
-		Select("country").
-		Group("country").
-		Pluck("country", &countries)
-
-	for _, country := range countries {
-		var count int64
-		DB.Model(&VisitRecord{}).Where("country = ?", country).Count(&count)
-		countryStats[country] = count
-	}
+	// 按语言、设备、国家统计
+	languageStats := countVisitsBy("language", includeBots)
+	deviceStats := countVisitsBy("device", includeBots)
+	countryStats := countVisitsBy("country", includeBots)
 
 	return &response.VisitOverviewResult{
 		TodayVisits:         todayVisits,
@@ -493,9 +522,33 @@ func GetVisitOverview() (*response.VisitOverviewResult, error) {
 	}, nil
 }
 
+// countVisitsBy 按列分组统计访问量（读取 daily_stats）
+func countVisitsBy(column string, includeBots bool) map[string]int64 {
+	type row struct {
+		Name  string
+		Count int64
+	}
+	var rows []row
+	DB.Model(&DailyStats{}).
+		Scopes(withBots(includeBots)).
+		Where("language != ?", allLanguages).
+		Select("COALESCE(" + column + ", '') as name, SUM(visits) as count").
+		Group(column).
+		Scan(&rows)
+
+	stats := make(map[string]int64)
+	for _, r := range rows {
+		stats[r.Name] += r.Count
+	}
+	return stats
+}
+
+// BeforeCreate 保留入队时记录的访问时间，异步写入时不以落库时间为准
 func (visitRecord *VisitRecord) BeforeCreate(tx *gorm.DB) error {
 	now := time.Now()
-	visitRecord.CreatedOn = now
+	if visitRecord.CreatedOn.IsZero() {
+		visitRecord.CreatedOn = now
+	}
 	visitRecord.ModifiedOn = now
 	return nil
 }
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+// reportLoc 报表时区，决定“今天”、按天去重、daily_stats 的日期和趋势的时间段划分
+var reportLoc = time.Local
+
+// ErrInvalidTimeZone 时区名称不合法
+var ErrInvalidTimeZone = errors.New("invalid time zone")
+
+// SetReportTimeZone 设置报表时区（IANA 名称，如 Asia/Shanghai），为空时使用服务器本地时区
+// 需在开始写入访问记录前调用；修改后已有的 daily_stats 仍按原时区划分日期，可通过 -rebuild-daily-stats 重建
+func SetReportTimeZone(name string) error {
+	if name == "" {
+		reportLoc = time.Local
+		return nil
+	}
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	reportLoc = loc
+	return nil
+}
+
+// ReportLocation 当前的报表时区
+func ReportLocation() *time.Location {
+	return reportLoc
+}
+
+// LoadLocation 解析时区名称，为空时返回报表时区
+// 名称会拼入 SQL（PostgreSQL 的 AT TIME ZONE），只允许 IANA 名称中出现的字符
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return reportLoc, nil
+	}
+	if len(name) > 64 {
+		return nil, ErrInvalidTimeZone
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '/' || r == '_' || r == '-' || r == '+') {
+			return nil, ErrInvalidTimeZone
+		}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
+
+// isReportLocation loc 是否与报表时区相同，相同时可以直接使用按报表时区预聚合的 daily_stats
+func isReportLocation(loc *time.Location) bool {
+	return loc.String() == reportLoc.String()
+}
+
+// today 报表时区的当天日期，与 dateExpr 输出格式一致
+func today() string {
+	return todayIn(reportLoc)
+}
+
+// todayIn 指定时区的当天日期
+func todayIn(loc *time.Location) string {
+	return time.Now().In(loc).Format("2006-01-02")
+}
+
+// recentStart 报表时区中最近 days 天（含今天）的第一天
+func recentStart(days int) string {
+	return time.Now().In(reportLoc).AddDate(0, 0, -days+1).Format("2006-01-02")
+}
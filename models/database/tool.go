@@ -0,0 +1,170 @@
+package database
+
+import (
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+	"gorm.io/gorm"
+)
+
+// Tool 工具目录，对应 init_db.sql 中的 tools 表
+type Tool struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	Category    string         `gorm:"size:100" json:"category"`
+	Icon        string         `gorm:"size:255" json:"icon"`
+	URL         string         `gorm:"column:url;size:500" json:"url"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 沿用 init_db.sql 中的表名
+func (Tool) TableName() string {
+	return "tools"
+}
+
+// ToolUsage 工具使用记录，对应 init_db.sql 中的 tool_usages 表
+type ToolUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ToolID    uint      `gorm:"index" json:"tool_id"`
+	IP        string    `gorm:"column:ip_address;size:45" json:"ip"`
+	UserAgent string    `gorm:"type:text" json:"user_agent"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 沿用 init_db.sql 中的表名
+func (ToolUsage) TableName() string {
+	return "tool_usages"
+}
+
+// GetTools 获取工具列表，可按分类过滤，activeOnly 时只返回启用的工具
+func GetTools(category string, activeOnly bool) ([]Tool, error) {
+	query := DB.Model(&Tool{})
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	var tools []Tool
+	err := query.Order("id").Find(&tools).Error
+	return tools, err
+}
+
+// GetTool 按 ID 获取工具
+func GetTool(id uint) (*Tool, error) {
+	var tool Tool
+	if err := DB.First(&tool, id).Error; err != nil {
+		return nil, err
+	}
+	return &tool, nil
+}
+
+// AddTool 新增工具
+func AddTool(tool *Tool) error {
+	return DB.Create(tool).Error
+}
+
+// EditTool 更新工具字段
+func EditTool(id uint, data map[string]interface{}) (*Tool, error) {
+	tool, err := GetTool(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := DB.Model(tool).Updates(data).Error; err != nil {
+		return nil, err
+	}
+	return GetTool(id)
+}
+
+// DeleteTool 软删除工具
+func DeleteTool(id uint) error {
+	res := DB.Delete(&Tool{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AddToolUsage 记录一次工具使用
+func AddToolUsage(usage *ToolUsage) error {
+	return DB.Create(usage).Error
+}
+
+// GetToolTrend 单个工具最近N天的使用趋势（按天聚合）
+func GetToolTrend(toolID uint, days int) (*response.ToolTrendResult, error) {
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	start := recentStart(days)
+
+	type row struct {
+		Date        string
+		Uses        int
+		UniqueUsers int
+	}
+	var rows []row
+	err := DB.Model(&ToolUsage{}).
+		Where("tool_id = ?", toolID).
+		Where(dateExpr("created_at")+" >= ?", start).
+		Select(dateExpr("created_at") + " as date, COUNT(*) as uses, COUNT(DISTINCT ip_address) as unique_users").
+		Group(dateExpr("created_at")).
+		Order("date").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rowMap := make(map[string]row)
+	for _, r := range rows {
+		rowMap[r.Date] = r
+	}
+
+	points := make([]response.ToolTrendPoint, 0, days)
+	startTime, _ := time.Parse("2006-01-02", start)
+	for i := 0; i < days; i++ {
+		d := startTime.AddDate(0, 0, i).Format("2006-01-02")
+		points = append(points, response.ToolTrendPoint{
+			Date:        d,
+			Uses:        rowMap[d].Uses,
+			UniqueUsers: rowMap[d].UniqueUsers,
+		})
+	}
+	return &response.ToolTrendResult{ToolID: toolID, Points: points}, nil
+}
+
+// GetTopTools 工具使用排行（可限制数量和日期范围）
+func GetTopTools(limit int, startDate, endDate string) ([]response.ToolStat, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	query := DB.Table("tool_usages").
+		Joins("JOIN tools ON tools.id = tool_usages.tool_id AND tools.deleted_at IS NULL")
+	if startDate != "" {
+		query = query.Where(dateExpr("tool_usages.created_at")+" >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where(dateExpr("tool_usages.created_at")+" <= ?", endDate)
+	}
+
+	var stats []response.ToolStat
+	err := query.Select("tools.id as tool_id, tools.name as name, COUNT(*) as count, COUNT(DISTINCT tool_usages.ip_address) as unique_users").
+		Group("tools.id, tools.name").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []response.ToolStat{}
+	}
+	return stats, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetToolTrend(t *testing.T) {
+	setupTestDB(t)
+	// 与服务器时区相差较大的报表时区，“今天”和按天分组都应以它为准
+	if err := SetReportTimeZone("Pacific/Kiritimati"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetReportTimeZone("") })
+
+	now := time.Now()
+	usages := []*ToolUsage{
+		{ToolID: 1, IP: "1.1.1.1", CreatedAt: now},
+		{ToolID: 1, IP: "1.1.1.1", CreatedAt: now},
+		{ToolID: 1, IP: "2.2.2.2", CreatedAt: now},
+		{ToolID: 1, IP: "1.1.1.1", CreatedAt: now.AddDate(0, 0, -2)},
+		// 超出范围和其他工具的使用记录不计入
+		{ToolID: 1, IP: "1.1.1.1", CreatedAt: now.AddDate(0, 0, -10)},
+		{ToolID: 2, IP: "1.1.1.1", CreatedAt: now},
+	}
+	for _, usage := range usages {
+		if err := AddToolUsage(usage); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := GetToolTrend(1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Points) != 7 {
+		t.Fatalf("points = %d, want 7", len(res.Points))
+	}
+	if first, want := res.Points[0].Date, recentStart(7); first != want {
+		t.Errorf("first date = %s, want %s", first, want)
+	}
+	last := res.Points[6]
+	if last.Date != today() || last.Uses != 3 || last.UniqueUsers != 2 {
+		t.Errorf("today = %+v, want %s with 3 uses by 2 users", last, today())
+	}
+	if p := res.Points[4]; p.Uses != 1 || p.UniqueUsers != 1 {
+		t.Errorf("two days ago = %+v, want 1 use", p)
+	}
+	total := 0
+	for _, p := range res.Points {
+		total += p.Uses
+	}
+	if total != 4 {
+		t.Errorf("total uses = %d, want 4", total)
+	}
+}
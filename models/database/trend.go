@@ -0,0 +1,217 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+// 趋势的时间粒度
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week" // 周一开始
+	GranularityMonth = "month"
+)
+
+// trendMaxPoints 单次趋势查询的最大时间段数
+const trendMaxPoints = 1000
+
+var (
+	// ErrInvalidGranularity 时间粒度不合法
+	ErrInvalidGranularity = errors.New("granularity must be hour, day, week or month")
+	// ErrInvalidRange 结束时间早于开始时间或时间段过多
+	ErrInvalidRange = errors.New("invalid time range")
+)
+
+// ValidGranularity 是否为支持的时间粒度
+func ValidGranularity(granularity string) bool {
+	switch granularity {
+	case GranularityHour, GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// bucketStart 返回 t 所在时间段的开始时间
+func bucketStart(t time.Time, granularity string) time.Time {
+	y, m, d := t.Date()
+	switch granularity {
+	case GranularityHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// nextBucket 返回下一个时间段的开始时间（按日历计算，跨夏令时也保持整点/零点）
+func nextBucket(t time.Time, granularity string, n int) time.Time {
+	switch granularity {
+	case GranularityHour:
+		return t.Add(time.Duration(n) * time.Hour)
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7*n)
+	case GranularityMonth:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// bucketLabel 时间段的显示名称：小时 YYYY-MM-DD HH:00，天和周（周一）YYYY-MM-DD，月 YYYY-MM
+func bucketLabel(t time.Time, granularity string) string {
+	switch granularity {
+	case GranularityHour:
+		return t.Format("2006-01-02 15:00")
+	case GranularityMonth:
+		return t.Format("2006-01")
+	}
+	return t.Format("2006-01-02")
+}
+
+// GetTrend 返回 [start, end] 按粒度划分的访问量、独立访客、独立会话，没有数据的时间段补 0
+// 时间段按 start 所在的时区划分；compare 为 true 时同时返回紧邻的上一个等长周期及汇总对比
+// 报表时区的天、周、月读取 daily_stats，周和月的独立访客/会话为每天去重结果之和；
+// 按小时或其他时区统计时读取原始访问记录，只覆盖保留期内的数据
+func GetTrend(start, end time.Time, granularity string, compare bool, language string, includeBots bool) (*response.TrendResult, error) {
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	if !ValidGranularity(granularity) {
+		return nil, ErrInvalidGranularity
+	}
+	first := bucketStart(start, granularity)
+	last := bucketStart(end, granularity)
+	if last.Before(first) {
+		return nil, ErrInvalidRange
+	}
+	n := 0
+	for t := first; !t.After(last); t = nextBucket(t, granularity, 1) {
+		if n++; n > trendMaxPoints {
+			return nil, ErrInvalidRange
+		}
+	}
+
+	points, err := trendPoints(first, n, granularity, language, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	res := &response.TrendResult{
+		Granularity: granularity,
+		Start:       bucketLabel(first, granularity),
+		End:         bucketLabel(last, granularity),
+		Points:      points,
+		Totals:      sumTrend(points),
+	}
+	if !compare {
+		return res, nil
+	}
+
+	previous, err := trendPoints(nextBucket(first, granularity, -n), n, granularity, language, includeBots)
+	if err != nil {
+		return nil, err
+	}
+	res.Previous = previous
+	prev := sumTrend(previous)
+	res.Comparison = &response.TrendComparison{
+		Visits:         compareMetric(res.Totals.Visits, prev.Visits),
+		UniqueVisitors: compareMetric(res.Totals.UniqueVisitors, prev.UniqueVisitors),
+		UniqueSessions: compareMetric(res.Totals.UniqueSessions, prev.UniqueSessions),
+	}
+	return res, nil
+}
+
+// trendPoints 从 first 开始连续 n 个时间段的数据
+func trendPoints(first time.Time, n int, granularity, language string, includeBots bool) ([]response.TrendPoint, error) {
+	end := nextBucket(first, granularity, n)
+	type row struct {
+		Date           string
+		Visits         int
+		UniqueVisitors int
+		UniqueSessions int
+	}
+	var rows []row
+	var err error
+	loc := first.Location()
+	expr, layout := dateExprIn("created_on", loc), "2006-01-02"
+	if granularity == GranularityHour {
+		expr, layout = hourExprIn("created_on", loc), "2006-01-02 15"
+	}
+	if granularity == GranularityHour || !isReportLocation(loc) {
+		// 按小时或按报表时区以外的时区统计时读取原始记录，与 dateExpr 一样按文本比较
+		err = DB.Model(&VisitRecord{}).
+			Scopes(withLanguage(language), withBots(includeBots)).
+			Where(expr+" >= ? AND "+expr+" < ?", first.Format(layout), end.Format(layout)).
+			Select(expr + " as date, COUNT(*) as visits, COUNT(DISTINCT " + visitorExpr() + ") as unique_visitors, COUNT(DISTINCT session_id) as unique_sessions").
+			Group(expr).
+			Scan(&rows).Error
+	} else {
+		// 未指定语言时独立访客/会话只读取跨语言汇总行，汇总行的访问量为 0
+		ul := uniqueLanguage(language)
+		err = DB.Model(&DailyStats{}).
+			Where("date >= ? AND date < ?", first.Format("2006-01-02"), end.Format("2006-01-02")).
+			Scopes(withLanguage(language), withBots(includeBots)).
+			Select("date, SUM(visits) as visits, "+
+				"SUM(CASE WHEN language = ? THEN unique_visitors ELSE 0 END) as unique_visitors, "+
+				"SUM(CASE WHEN language = ? THEN unique_sessions ELSE 0 END) as unique_sessions", ul, ul).
+			Group("date").
+			Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 合并到完整连续的时间段序列
+	byBucket := make(map[string]*response.TrendPoint, len(rows))
+	for _, r := range rows {
+		t, err := time.ParseInLocation(layout, r.Date, loc)
+		if err != nil {
+			continue
+		}
+		key := bucketLabel(bucketStart(t, granularity), granularity)
+		p, ok := byBucket[key]
+		if !ok {
+			p = &response.TrendPoint{Date: key}
+			byBucket[key] = p
+		}
+		p.Visits += r.Visits
+		p.UniqueVisitors += r.UniqueVisitors
+		p.UniqueSessions += r.UniqueSessions
+	}
+
+	points := make([]response.TrendPoint, 0, n)
+	for t := first; t.Before(end); t = nextBucket(t, granularity, 1) {
+		key := bucketLabel(t, granularity)
+		if p, ok := byBucket[key]; ok {
+			points = append(points, *p)
+		} else {
+			points = append(points, response.TrendPoint{Date: key})
+		}
+	}
+	return points, nil
+}
+
+// sumTrend 汇总各时间段的数据
+func sumTrend(points []response.TrendPoint) response.TrendTotals {
+	var totals response.TrendTotals
+	for _, p := range points {
+		totals.Visits += p.Visits
+		totals.UniqueVisitors += p.UniqueVisitors
+		totals.UniqueSessions += p.UniqueSessions
+	}
+	return totals
+}
+
+// compareMetric 本期与上期的差值和变化百分比，上期为 0 时百分比为空
+func compareMetric(current, previous int) response.MetricChange {
+	change := response.MetricChange{Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		percent := float64(current-previous) / float64(previous) * 100
+		change.Percent = &percent
+	}
+	return change
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/webbleen/go-gin/models/response"
+)
+
+func TestBucketing(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	at := time.Date(2024, 3, 6, 15, 42, 10, 0, loc) // 周三
+
+	tests := []struct {
+		granularity string
+		start       string
+		next        string
+		label       string
+	}{
+		{GranularityHour, "2024-03-06 15:00", "2024-03-06 16:00", "2024-03-06 15:00"},
+		{GranularityDay, "2024-03-06 00:00", "2024-03-07 00:00", "2024-03-06"},
+		{GranularityWeek, "2024-03-04 00:00", "2024-03-11 00:00", "2024-03-04"},
+		{GranularityMonth, "2024-03-01 00:00", "2024-04-01 00:00", "2024-03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.granularity, func(t *testing.T) {
+			start := bucketStart(at, tt.granularity)
+			if got := start.Format("2006-01-02 15:04"); got != tt.start {
+				t.Errorf("bucketStart = %s, want %s", got, tt.start)
+			}
+			if start.Location() != loc {
+				t.Errorf("bucketStart changed the location to %s", start.Location())
+			}
+			if got := nextBucket(start, tt.granularity, 1).Format("2006-01-02 15:04"); got != tt.next {
+				t.Errorf("nextBucket = %s, want %s", got, tt.next)
+			}
+			if got := bucketLabel(start, tt.granularity); got != tt.label {
+				t.Errorf("bucketLabel = %s, want %s", got, tt.label)
+			}
+		})
+	}
+
+	// 周日属于前一个周一开始的周
+	sunday := time.Date(2024, 3, 10, 23, 0, 0, 0, loc)
+	if got := bucketLabel(bucketStart(sunday, GranularityWeek), GranularityWeek); got != "2024-03-04" {
+		t.Errorf("week of Sunday = %s, want 2024-03-04", got)
+	}
+	// 月末加一个月不会溢出到下下个月
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	if got := bucketLabel(nextBucket(jan, GranularityMonth, 1), GranularityMonth); got != "2024-02" {
+		t.Errorf("month after 2024-01 = %s, want 2024-02", got)
+	}
+}
+
+func TestGetTrend(t *testing.T) {
+	setupTestDB(t)
+
+	addVisits(t, []*VisitRecord{
+		visitAt("2024-03-02", "12:00", "9.9.9.9", "p1", "zh", false),
+		visitAt("2024-03-04", "10:15", "1.1.1.1", "s1", "zh", false),
+		visitAt("2024-03-04", "10:45", "1.1.1.1", "s1", "zh", false),
+		visitAt("2024-03-04", "12:05", "2.2.2.2", "s2", "en", false),
+		visitAt("2024-03-05", "11:00", "66.249.66.1", "bot", "zh", true),
+		visitAt("2024-03-06", "09:00", "1.1.1.1", "s3", "zh", false),
+	})
+	day := func(date string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		return t
+	}
+	point := func(date string, visits, visitors, sessions int) response.TrendPoint {
+		return response.TrendPoint{Date: date, Visits: visits, UniqueVisitors: visitors, UniqueSessions: sessions}
+	}
+
+	tests := []struct {
+		name        string
+		start, end  time.Time
+		granularity string
+		language    string
+		includeBots bool
+		want        []response.TrendPoint
+	}{
+		{
+			name:        "days fill gaps and exclude bots",
+			start:       day("2024-03-04"),
+			end:         day("2024-03-06"),
+			granularity: GranularityDay,
+			want:        []response.TrendPoint{point("2024-03-04", 3, 2, 2), point("2024-03-05", 0, 0, 0), point("2024-03-06", 1, 1, 1)},
+		},
+		{
+			name:        "days with bots and language",
+			start:       day("2024-03-04"),
+			end:         day("2024-03-05"),
+			granularity: GranularityDay,
+			language:    "zh",
+			includeBots: true,
+			want:        []response.TrendPoint{point("2024-03-04", 2, 1, 1), point("2024-03-05", 1, 1, 1)},
+		},
+		{
+			name:        "week sums daily uniques",
+			start:       day("2024-03-06"),
+			end:         day("2024-03-06"),
+			granularity: GranularityWeek,
+			want:        []response.TrendPoint{point("2024-03-04", 4, 3, 3)},
+		},
+		{
+			name:        "hours read raw records",
+			start:       day("2024-03-04").Add(10 * time.Hour),
+			end:         day("2024-03-04").Add(12 * time.Hour),
+			granularity: GranularityHour,
+			want:        []response.TrendPoint{point("2024-03-04 10:00", 2, 1, 1), point("2024-03-04 11:00", 0, 0, 0), point("2024-03-04 12:00", 1, 1, 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := GetTrend(tt.start, tt.end, tt.granularity, false, tt.language, tt.includeBots)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Points, tt.want) {
+				t.Errorf("points = %+v, want %+v", res.Points, tt.want)
+			}
+		})
+	}
+
+	res, err := GetTrend(day("2024-03-04"), day("2024-03-06"), GranularityDay, true, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPrevious := []response.TrendPoint{point("2024-03-01", 0, 0, 0), point("2024-03-02", 1, 1, 1), point("2024-03-03", 0, 0, 0)}
+	if !reflect.DeepEqual(res.Previous, wantPrevious) {
+		t.Errorf("previous = %+v, want %+v", res.Previous, wantPrevious)
+	}
+	if c := res.Comparison.Visits; c.Current != 4 || c.Previous != 1 || c.Delta != 3 || c.Percent == nil || *c.Percent != 300 {
+		t.Errorf("visits comparison = %+v", c)
+	}
+
+	if _, err := GetTrend(day("2024-03-06"), day("2024-03-04"), GranularityDay, false, "", false); err != ErrInvalidRange {
+		t.Errorf("reversed range error = %v, want %v", err, ErrInvalidRange)
+	}
+	if _, err := GetTrend(day("2024-03-04"), day("2024-03-06"), "year", false, "", false); err != ErrInvalidGranularity {
+		t.Errorf("invalid granularity error = %v, want %v", err, ErrInvalidGranularity)
+	}
+}
@@ -0,0 +1,25 @@
+package response
+
+// 机器人流量报告
+type BotStatsResult struct {
+	Days        int             `json:"days"`
+	TotalVisits int             `json:"total_visits"`
+	BotVisits   int             `json:"bot_visits"`
+	BotShare    float64         `json:"bot_share"` // 机器人访问占比（0-1）
+	Bots        []BotStat       `json:"bots"`
+	TopPages    []PageStat      `json:"top_pages"`
+	Points      []BotTrendPoint `json:"points"`
+}
+
+// 按机器人名称统计
+type BotStat struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// 机器人/真人访问趋势点（按天聚合）
+type BotTrendPoint struct {
+	Date   string `json:"date"`
+	Bots   int    `json:"bots"`
+	Humans int    `json:"humans"`
+}
@@ -0,0 +1,17 @@
+package response
+
+// 聊天消息（用于响应）
+type ChatMessage struct {
+	ID        int    `json:"id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Type      string `json:"type"`
+	CreatedOn string `json:"created_on"`
+}
+
+// 聊天消息分页结果
+type ChatMessagesResult struct {
+	SessionID  string        `json:"session_id"`
+	Messages   []ChatMessage `json:"messages"`
+	Pagination Pagination    `json:"pagination"`
+}
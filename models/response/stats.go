@@ -16,20 +16,33 @@ type VisitRecordsResult struct {
 
 // 访问记录（用于响应）
 type VisitRecord struct {
-	ID         int    `json:"id"`
-	IP         string `json:"ip"`
-	UserAgent  string `json:"user_agent"`
-	Referer    string `json:"referer"`
-	Page       string `json:"page"`
-	SessionID  string `json:"session_id"`
-	Country    string `json:"country"`
-	City       string `json:"city"`
-	Device     string `json:"device"`
-	Browser    string `json:"browser"`
-	OS         string `json:"os"`
-	Language   string `json:"language"`
-	CreatedOn  string `json:"created_on"`
-	ModifiedOn string `json:"modified_on"`
+	ID             int    `json:"id"`
+	IP             string `json:"ip"`
+	UserAgent      string `json:"user_agent"`
+	Referer        string `json:"referer"`
+	Page           string `json:"page"`
+	SessionID      string `json:"session_id"`
+	Country        string `json:"country"`
+	City           string `json:"city"`
+	Device         string `json:"device"`
+	Browser        string `json:"browser"`
+	OS             string `json:"os"`
+	Language       string `json:"language"`
+	BrowserVersion string `json:"browser_version"`
+	OSVersion      string `json:"os_version"`
+	IsBot          bool   `json:"is_bot"`
+	BotName        string `json:"bot_name"`
+	ReferrerDomain string `json:"referrer_domain"`
+	Channel        string `json:"channel"`
+	UTMSource      string `json:"utm_source"`
+	UTMMedium      string `json:"utm_medium"`
+	UTMCampaign    string `json:"utm_campaign"`
+	SearchTerm     string `json:"search_term"`
+	SearchType     string `json:"search_type"`
+	Duration       int    `json:"duration"`
+	ScrollDepth    int    `json:"scroll_depth"`
+	CreatedOn      string `json:"created_on"`
+	ModifiedOn     string `json:"modified_on"`
 }
 
 // 访问统计概览结果
@@ -83,7 +96,7 @@ type PageStat struct {
     Count int    `json:"count"`
 }
 
-// 趋势点（按天聚合）
+// 趋势点，date 为时间段的开始：小时 YYYY-MM-DD HH:00，天和周 YYYY-MM-DD，月 YYYY-MM
 type TrendPoint struct {
     Date            string `json:"date"`
     Visits          int    `json:"visits"`
@@ -92,7 +105,35 @@ type TrendPoint struct {
 }
 
 type TrendResult struct {
-    Points []TrendPoint `json:"points"`
+    Granularity string           `json:"granularity"`
+    Start       string           `json:"start"`
+    End         string           `json:"end"`
+    Points      []TrendPoint     `json:"points"`
+    Totals      TrendTotals      `json:"totals"`
+    Previous    []TrendPoint     `json:"previous,omitempty"`   // 上一个等长周期，compare=true 时返回
+    Comparison  *TrendComparison `json:"comparison,omitempty"` // 与上一周期的汇总对比
+}
+
+// 趋势汇总
+type TrendTotals struct {
+    Visits         int `json:"visits"`
+    UniqueVisitors int `json:"unique_visitors"`
+    UniqueSessions int `json:"unique_sessions"`
+}
+
+// 与上一周期的对比
+type TrendComparison struct {
+    Visits         MetricChange `json:"visits"`
+    UniqueVisitors MetricChange `json:"unique_visitors"`
+    UniqueSessions MetricChange `json:"unique_sessions"`
+}
+
+// 指标变化，上一周期为 0 时 percent 为 null
+type MetricChange struct {
+    Current  int      `json:"current"`
+    Previous int      `json:"previous"`
+    Delta    int      `json:"delta"`
+    Percent  *float64 `json:"percent"`
 }
 
 // 日统计（与趋势结构一致）
@@ -107,3 +148,160 @@ type ContentStatsResponse struct {
     TotalCategories int    `json:"total_categories"`
     LastUpdate      string `json:"last_update"`
 }
+
+// 批量上报结果
+type BatchResult struct {
+	Total    int               `json:"total"`
+	Recorded int               `json:"recorded"`
+	Items    []BatchItemResult `json:"items"`
+}
+
+// 批量上报中单条数据的处理结果
+type BatchItemResult struct {
+	Index    int    `json:"index"`
+	Type     string `json:"type"`
+	Recorded bool   `json:"recorded"`
+	Reason   string `json:"reason"`          // new_visit / new_event / engagement_updated / already_exists / invalid / error
+	Error    string `json:"error,omitempty"` // reason 为 invalid 或 error 时的原因
+}
+
+// 事件统计（结构与 PageStat 一致，用于热门事件）
+type EventStat struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// 单个事件的趋势和按属性分组结果
+type EventStatsResult struct {
+	Name     string              `json:"name"`
+	Property string              `json:"property,omitempty"`
+	Total    int                 `json:"total"`
+	Value    float64             `json:"value"` // value 字段合计
+	Points   []EventTrendPoint   `json:"points"`
+	Values   []EventPropertyStat `json:"values,omitempty"`
+}
+
+// 事件趋势点（按天聚合）
+type EventTrendPoint struct {
+	Date           string  `json:"date"`
+	Count          int     `json:"count"`
+	UniqueSessions int     `json:"unique_sessions"`
+	Value          float64 `json:"value"`
+}
+
+// 按属性取值分组的事件次数
+type EventPropertyStat struct {
+	Value string  `json:"value"`
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum"` // value 字段合计
+}
+
+// 页面阅读数据
+type PageEngagementStat struct {
+	Page           string  `json:"page"`
+	Visits         int     `json:"visits"`
+	AvgDuration    float64 `json:"avg_duration"`    // 平均停留秒数（只计上报过阅读数据的访问）
+	BounceRate     float64 `json:"bounce_rate"`     // 跳出率（0-1）：会话当天只浏览了这一个页面
+	CompletionRate float64 `json:"completion_rate"` // 读完率（0-1）：滚动深度达到 90%
+}
+
+// 会话概览
+type SessionStatsResult struct {
+	Sessions    int        `json:"sessions"`
+	AvgDuration float64    `json:"avg_duration"` // 平均会话时长（秒）
+	AvgPages    float64    `json:"avg_pages"`    // 平均每个会话浏览的页面数
+	BounceRate  float64    `json:"bounce_rate"`  // 只浏览了一个页面的会话占比（0-1）
+	EntryPages  []PageStat `json:"entry_pages"`
+	ExitPages   []PageStat `json:"exit_pages"`
+}
+
+// 会话内的页面跳转
+type PageTransition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// 漏斗分析结果
+type FunnelResult struct {
+	Steps []FunnelStep `json:"steps"`
+}
+
+// 漏斗步骤
+type FunnelStep struct {
+	Pattern           string  `json:"pattern"`
+	Sessions          int     `json:"sessions"`           // 依次到达该步骤的会话数
+	DropOff           int     `json:"drop_off"`           // 上一步到达但本步未到达的会话数
+	Conversion        float64 `json:"conversion"`         // 相对上一步的转化率（0-1）
+	OverallConversion float64 `json:"overall_conversion"` // 相对第一步的转化率（0-1）
+}
+
+// 路径分析结果
+type PathResult struct {
+	From     string     `json:"from"`
+	Sessions int        `json:"sessions"` // 访问过起始页面的会话数
+	Paths    []PathStat `json:"paths"`
+}
+
+// 从起始页面出发的一条路径
+type PathStat struct {
+	Pages []string `json:"pages"`
+	Count int      `json:"count"`
+}
+
+// 来源统计
+type ReferrerStatsResult struct {
+	Channels  []ChannelStat  `json:"channels"`
+	Referrers []ReferrerStat `json:"referrers"`
+}
+
+// 按渠道统计的访问量
+type ChannelStat struct {
+	Channel string `json:"channel"`
+	Count   int    `json:"count"`
+}
+
+// 按来源域名统计的访问量
+type ReferrerStat struct {
+	Domain  string `json:"domain"`
+	Channel string `json:"channel"`
+	Count   int    `json:"count"`
+}
+
+// 按推广活动统计的访问量
+type CampaignStat struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Visits   int    `json:"visits"`
+	Sessions int    `json:"sessions"`
+}
+
+// 搜索关键词统计
+type SearchTermStat struct {
+	Term     string `json:"term"`
+	Type     string `json:"type"` // engine（搜索引擎）或 site（站内搜索）
+	Count    int    `json:"count"`
+	Sessions int    `json:"sessions"`
+}
+
+// 无结果的站内搜索
+type ZeroResultSearch struct {
+	Term     string `json:"term"`
+	Count    int    `json:"count"`
+	Sessions int    `json:"sessions"`
+}
+
+// 搜索关键词每天的次数
+type SearchTrendPoint struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// 搜索关键词趋势
+type SearchTrendResult struct {
+	Term   string             `json:"term"`
+	Type   string             `json:"type"`
+	Total  int                `json:"total"`
+	Points []SearchTrendPoint `json:"points"`
+}
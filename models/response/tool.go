@@ -0,0 +1,21 @@
+package response
+
+// 工具使用排行
+type ToolStat struct {
+	ToolID      uint   `json:"tool_id"`
+	Name        string `json:"name"`
+	Count       int    `json:"count"`
+	UniqueUsers int    `json:"unique_users"`
+}
+
+// 工具使用趋势点（按天聚合）
+type ToolTrendPoint struct {
+	Date        string `json:"date"`
+	Uses        int    `json:"uses"`
+	UniqueUsers int    `json:"unique_users"`
+}
+
+type ToolTrendResult struct {
+	ToolID uint             `json:"tool_id"`
+	Points []ToolTrendPoint `json:"points"`
+}
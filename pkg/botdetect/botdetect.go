@@ -0,0 +1,151 @@
+package botdetect
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/webbleen/go-gin/pkg/iputil"
+	"github.com/webbleen/go-gin/pkg/useragent"
+)
+
+// 非 User-Agent 规则命中时使用的名称
+const (
+	NameHeadless    = "Headless"
+	NameEmptyUA     = "Empty UA"
+	NameCrawlerIP   = "Crawler IP"
+	ReasonUserAgent = "user_agent"
+	ReasonIP        = "ip"
+	ReasonHeadless  = "headless"
+)
+
+//go:embed crawler_ips.txt
+var embeddedRanges []byte
+
+// Signals 判定所需的请求特征
+type Signals struct {
+	UserAgent      string
+	Parsed         useragent.Result
+	IP             string
+	AcceptLanguage string
+	// Webdriver 前端上报的 navigator.webdriver
+	Webdriver bool
+}
+
+// Result 判定结果
+type Result struct {
+	IsBot  bool
+	Name   string
+	Reason string
+}
+
+type ipRange struct {
+	name   string
+	prefix netip.Prefix
+}
+
+var (
+	ranges []ipRange
+	mu     sync.RWMutex
+	// requireAcceptLanguage 为 true 时，浏览器 UA 未携带 Accept-Language 即判定为无头浏览器
+	requireAcceptLanguage bool
+)
+
+func init() {
+	parsed, err := parseRanges(embeddedRanges)
+	if err != nil {
+		panic(fmt.Sprintf("botdetect: invalid embedded ranges: %v", err))
+	}
+	ranges = parsed
+}
+
+// LoadRangesFile 使用外部文件替换爬虫 IP 段
+func LoadRangesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseRanges(data)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	ranges = parsed
+	mu.Unlock()
+	return nil
+}
+
+// SetRequireAcceptLanguage 设置是否把缺少 Accept-Language 的浏览器请求单独判定为机器人。
+// 部分隐私插件、代理和 WebView 也会去掉该请求头，默认只把它当作弱特征，不单独作为判定依据
+func SetRequireAcceptLanguage(require bool) {
+	mu.Lock()
+	requireAcceptLanguage = require
+	mu.Unlock()
+}
+
+// Detect 依次根据 User-Agent、已知爬虫 IP 段和无头浏览器特征判断是否为机器人
+func Detect(s Signals) Result {
+	if strings.TrimSpace(s.UserAgent) == "" {
+		return Result{IsBot: true, Name: NameEmptyUA, Reason: ReasonUserAgent}
+	}
+	if s.Parsed.IsBot {
+		return Result{IsBot: true, Name: s.Parsed.BotName, Reason: ReasonUserAgent}
+	}
+	if name, ok := MatchCrawlerIP(s.IP); ok {
+		return Result{IsBot: true, Name: name, Reason: ReasonIP}
+	}
+	// 无头浏览器：webdriver 标记；浏览器 UA 却不带 Accept-Language 仅在开启严格模式时单独生效
+	if s.Webdriver || (s.Parsed.Browser != "" && s.AcceptLanguage == "" && strictAcceptLanguage()) {
+		return Result{IsBot: true, Name: NameHeadless, Reason: ReasonHeadless}
+	}
+	return Result{}
+}
+
+func strictAcceptLanguage() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return requireAcceptLanguage
+}
+
+// MatchCrawlerIP 判断 IP 是否属于已知爬虫 IP 段，返回爬虫名称
+func MatchCrawlerIP(ip string) (string, bool) {
+	addr, ok := iputil.Parse(ip)
+	if !ok {
+		return "", false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, r := range ranges {
+		if r.prefix.Contains(addr) {
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+func parseRanges(data []byte) ([]ipRange, error) {
+	var result []ipRange
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		prefix, err := netip.ParsePrefix(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ipRange{name: fields[0], prefix: prefix.Masked()})
+	}
+	return result, scanner.Err()
+}
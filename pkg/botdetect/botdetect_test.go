@@ -0,0 +1,134 @@
+package botdetect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webbleen/go-gin/pkg/useragent"
+)
+
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals Signals
+		want    Result
+	}{
+		{
+			name:    "real browser",
+			signals: Signals{UserAgent: chromeUA, IP: "203.0.113.7", AcceptLanguage: "en-US"},
+			want:    Result{},
+		},
+		{
+			name:    "empty user agent",
+			signals: Signals{UserAgent: " ", AcceptLanguage: "en-US"},
+			want:    Result{IsBot: true, Name: NameEmptyUA, Reason: ReasonUserAgent},
+		},
+		{
+			name:    "bot user agent",
+			signals: Signals{UserAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", AcceptLanguage: "en-US"},
+			want:    Result{IsBot: true, Name: "Bingbot", Reason: ReasonUserAgent},
+		},
+		{
+			name:    "crawler ip with browser user agent",
+			signals: Signals{UserAgent: chromeUA, IP: "66.249.66.1", AcceptLanguage: "en-US"},
+			want:    Result{IsBot: true, Name: "Googlebot", Reason: ReasonIP},
+		},
+		{
+			name:    "crawler ipv6",
+			signals: Signals{UserAgent: chromeUA, IP: "2001:4860:4801::1", AcceptLanguage: "en-US"},
+			want:    Result{IsBot: true, Name: "Googlebot", Reason: ReasonIP},
+		},
+		{
+			name:    "webdriver",
+			signals: Signals{UserAgent: chromeUA, IP: "203.0.113.7", AcceptLanguage: "en-US", Webdriver: true},
+			want:    Result{IsBot: true, Name: NameHeadless, Reason: ReasonHeadless},
+		},
+		{
+			// 缺少 Accept-Language 只是弱特征，单独出现时不判定为机器人
+			name:    "browser without accept-language",
+			signals: Signals{UserAgent: chromeUA, IP: "203.0.113.7"},
+			want:    Result{},
+		},
+		{
+			name:    "webdriver without accept-language",
+			signals: Signals{UserAgent: chromeUA, IP: "203.0.113.7", Webdriver: true},
+			want:    Result{IsBot: true, Name: NameHeadless, Reason: ReasonHeadless},
+		},
+		{
+			name:    "crawler ip without accept-language",
+			signals: Signals{UserAgent: chromeUA, IP: "66.249.66.1"},
+			want:    Result{IsBot: true, Name: "Googlebot", Reason: ReasonIP},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.signals.Parsed = useragent.Parse(tt.signals.UserAgent)
+			if got := Detect(tt.signals); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectRequireAcceptLanguage(t *testing.T) {
+	SetRequireAcceptLanguage(true)
+	t.Cleanup(func() { SetRequireAcceptLanguage(false) })
+
+	tests := []struct {
+		name    string
+		signals Signals
+		want    Result
+	}{
+		{"browser without accept-language", Signals{UserAgent: chromeUA}, Result{IsBot: true, Name: NameHeadless, Reason: ReasonHeadless}},
+		{"browser with accept-language", Signals{UserAgent: chromeUA, AcceptLanguage: "en-US"}, Result{}},
+	}
+	for _, tt := range tests {
+		tt.signals.Parsed = useragent.Parse(tt.signals.UserAgent)
+		if got := Detect(tt.signals); got != tt.want {
+			t.Errorf("%s: Detect() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRangesFile(t *testing.T) {
+	original := ranges
+	t.Cleanup(func() { ranges = original })
+
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	content := "# comment\n\nTestBot 198.51.100.0/24\nTestBot 2001:db8::/32\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadRangesFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		name string
+		ok   bool
+	}{
+		{"198.51.100.25", "TestBot", true},
+		{"2001:db8::5", "TestBot", true},
+		{"[2001:db8::5]:443", "TestBot", true},
+		{"66.249.66.1", "", false},
+		{"not-an-ip", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := MatchCrawlerIP(tt.ip)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("MatchCrawlerIP(%q) = %q, %v, want %q, %v", tt.ip, name, ok, tt.name, tt.ok)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.txt")
+	if err := os.WriteFile(bad, []byte("TestBot\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadRangesFile(bad); err == nil {
+		t.Error("LoadRangesFile accepted a line without a CIDR")
+	}
+}
@@ -0,0 +1,119 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webbleen/go-gin/pkg/setting"
+)
+
+// 消息角色，与 chat_messages.role 的约束一致
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 传给回复生成器的一条历史消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Responder 根据会话历史生成助手回复，history 按时间正序，最后一条为用户的新消息
+type Responder interface {
+	Reply(ctx context.Context, history []Message) (string, error)
+}
+
+// NewResponder 根据配置创建回复生成器
+func NewResponder() Responder {
+	switch setting.ChatProvider {
+	case "openai":
+		return &OpenAIResponder{
+			URL:    setting.ChatAPIURL,
+			APIKey: setting.ChatAPIKey,
+			Model:  setting.ChatModel,
+			Client: &http.Client{Timeout: setting.ChatTimeout},
+		}
+	default:
+		return EchoResponder{}
+	}
+}
+
+// EchoResponder 本地回显实现，用于开发和测试
+type EchoResponder struct{}
+
+// Reply 原样返回最后一条用户消息
+func (EchoResponder) Reply(ctx context.Context, history []Message) (string, error) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == RoleUser {
+			return history[i].Content, nil
+		}
+	}
+	return "", nil
+}
+
+// OpenAIResponder 调用兼容 OpenAI Chat Completions 协议的接口
+type OpenAIResponder struct {
+	URL    string
+	APIKey string
+	Model  string
+	Client *http.Client
+}
+
+// Reply 调用 {URL}/chat/completions 生成回复
+func (r *OpenAIResponder) Reply(ctx context.Context, history []Message) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":    r.Model,
+		"messages": history,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := strings.TrimRight(r.URL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.APIKey)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat completion failed: status %d", resp.StatusCode)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
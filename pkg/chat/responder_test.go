@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEchoResponder(t *testing.T) {
+	tests := []struct {
+		history []Message
+		want    string
+	}{
+		{nil, ""},
+		{[]Message{{RoleUser, "hi"}}, "hi"},
+		{[]Message{{RoleUser, "first"}, {RoleAssistant, "reply"}}, "first"},
+		{[]Message{{RoleUser, "first"}, {RoleAssistant, "reply"}, {RoleUser, "second"}}, "second"},
+	}
+	for _, tt := range tests {
+		got, err := EchoResponder{}.Reply(context.Background(), tt.history)
+		if err != nil || got != tt.want {
+			t.Errorf("Reply(%v) = %q, %v, want %q", tt.history, got, err, tt.want)
+		}
+	}
+}
+
+func TestOpenAIResponder(t *testing.T) {
+	history := []Message{{RoleUser, "hello"}, {RoleAssistant, "hi"}, {RoleUser, "how are you"}}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"first choice", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"fine"}},{"message":{"content":"other"}}]}`, "fine", false},
+		{"error status", http.StatusTooManyRequests, `{"error":{"message":"rate limited"}}`, "", true},
+		{"no choices", http.StatusOK, `{"choices":[]}`, "", true},
+		{"invalid json", http.StatusOK, `not json`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Model    string    `json:"model"`
+				Messages []Message `json:"messages"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("request = %s %s", r.Method, r.URL.Path)
+				}
+				if auth := r.Header.Get("Authorization"); auth != "Bearer sk-test" {
+					t.Errorf("Authorization = %q", auth)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			r := &OpenAIResponder{URL: server.URL + "/v1/", APIKey: "sk-test", Model: "test-model", Client: server.Client()}
+			reply, err := r.Reply(context.Background(), history)
+			if (err != nil) != tt.wantErr || reply != tt.want {
+				t.Errorf("Reply = %q, %v, want %q, error %v", reply, err, tt.want, tt.wantErr)
+			}
+			if got.Model != "test-model" || !reflect.DeepEqual(got.Messages, history) {
+				t.Errorf("request body = %+v", got)
+			}
+		})
+	}
+}
@@ -8,9 +8,18 @@ const (
 	ERROR_EXIST_TAG         = 10001
 	ERROR_NOT_EXIST_TAG     = 10002
 	ERROR_NOT_EXIST_ARTICLE = 10003
+	ERROR_NOT_EXIST_API_KEY = 10004
+	ERROR_NOT_EXIST_TOOL    = 10005
+	ERROR_NOT_EXIST_CHAT    = 10006
 
 	ERROR_AUTH_CHECK_TOKEN_FAIL    = 20001
 	ERROR_AUTH_CHECK_TOKEN_TIMEOUT = 20002
 	ERROR_AUTH_TOKEN               = 20003
 	ERROR_AUTH                     = 20004
+	ERROR_AUTH_LOGIN               = 20005
+	ERROR_AUTH_API_KEY             = 20006
+	ERROR_AUTH_API_KEY_SCOPE       = 20007
+
+	ERROR_CHAT_REPLY      = 30001
+	ERROR_CHAT_RATE_LIMIT = 30002
 )
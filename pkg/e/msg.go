@@ -7,10 +7,18 @@ var MsgFlags = map[int]string{
 	ERROR_EXIST_TAG:                "已存在该标签名称",
 	ERROR_NOT_EXIST_TAG:            "该标签不存在",
 	ERROR_NOT_EXIST_ARTICLE:        "该文章不存在",
+	ERROR_NOT_EXIST_API_KEY:        "该API Key不存在",
+	ERROR_NOT_EXIST_TOOL:           "该工具不存在",
+	ERROR_NOT_EXIST_CHAT:           "该聊天会话不存在",
 	ERROR_AUTH_CHECK_TOKEN_FAIL:    "Token鉴权失败",
 	ERROR_AUTH_CHECK_TOKEN_TIMEOUT: "Token已超时",
 	ERROR_AUTH_TOKEN:               "Token生成失败",
 	ERROR_AUTH:                     "Token错误",
+	ERROR_AUTH_LOGIN:               "账号或密码错误",
+	ERROR_AUTH_API_KEY:             "API Key无效",
+	ERROR_AUTH_API_KEY_SCOPE:       "API Key权限不足",
+	ERROR_CHAT_REPLY:               "生成回复失败",
+	ERROR_CHAT_RATE_LIMIT:          "发送消息过于频繁，请稍后再试",
 }
 
 func GetMsg(code int) string {
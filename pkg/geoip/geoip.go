@@ -0,0 +1,166 @@
+package geoip
+
+import (
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/webbleen/go-gin/pkg/iputil"
+)
+
+// Location 地理位置解析结果
+type Location struct {
+	Country string
+	City    string
+	Region  string
+}
+
+// record MaxMind GeoIP2/GeoLite2 City 数据库中用到的字段
+type record struct {
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+var (
+	reader       *maxminddb.Reader
+	dbPath       string
+	dbModTime    time.Time
+	locale       = "en"
+	httpFallback bool
+	mu           sync.RWMutex
+)
+
+// Open 打开 MMDB 文件并替换当前数据库，旧数据库在替换后关闭
+// 查询在读锁内完成，拿到写锁时已没有使用旧数据库的查询，关闭（munmap）是安全的
+func Open(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	old := reader
+	reader = db
+	dbPath = path
+	dbModTime = info.ModTime()
+	mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Watch 定期检查数据库文件的修改时间，文件更新后自动重新加载
+func Watch(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			mu.RLock()
+			path, modTime := dbPath, dbModTime
+			mu.RUnlock()
+			if path == "" {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(modTime) {
+				continue
+			}
+			if err := Open(path); err != nil {
+				log.Printf("重新加载 GeoIP 数据库失败: %v", err)
+				continue
+			}
+			log.Printf("已重新加载 GeoIP 数据库: %s", path)
+		}
+	}()
+}
+
+// SetLocale 设置返回的地名语言（如 en、zh-CN），数据库中没有该语言时回退到 en
+func SetLocale(l string) {
+	if l == "" {
+		return
+	}
+	mu.Lock()
+	locale = l
+	mu.Unlock()
+}
+
+// SetHTTPFallback 设置本地数据库未命中时是否调用第三方 HTTP 接口
+func SetHTTPFallback(enabled bool) {
+	mu.Lock()
+	httpFallback = enabled
+	mu.Unlock()
+}
+
+// Enabled 本地数据库是否已加载
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return reader != nil
+}
+
+// Lookup 解析 IP 的地理位置：优先查询本地数据库，未命中且开启回退时调用第三方接口
+// 私有地址和无效地址直接返回未命中
+func Lookup(ip string) (Location, bool) {
+	addr, ok := iputil.Parse(ip)
+	if !ok || iputil.IsReserved(addr) {
+		return Location{}, false
+	}
+
+	loc, ok, fallback := lookupLocal(net.IP(addr.AsSlice()))
+	if ok {
+		return loc, true
+	}
+	if fallback {
+		return lookupHTTP(addr.String())
+	}
+	return Location{}, false
+}
+
+// lookupLocal 查询本地数据库，同时返回是否开启了 HTTP 回退
+// 整个查询持有读锁，避免重新加载时关闭正在使用的数据库
+func lookupLocal(ip net.IP) (Location, bool, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if reader == nil {
+		return Location{}, false, httpFallback
+	}
+	var rec record
+	if err := reader.Lookup(ip, &rec); err != nil {
+		return Location{}, false, httpFallback
+	}
+	loc := Location{
+		Country: name(rec.Country.Names, locale),
+		City:    name(rec.City.Names, locale),
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = name(rec.Subdivisions[0].Names, locale)
+	}
+	return loc, loc.Country != "", httpFallback
+}
+
+func name(names map[string]string, lang string) string {
+	if v := names[lang]; v != "" {
+		return v
+	}
+	return names["en"]
+}
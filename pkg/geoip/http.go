@@ -0,0 +1,197 @@
+package geoip
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 第三方接口结果缓存时间，未命中的结果缓存较短时间，避免反复请求
+// 缓存按 IP 保存，超过 httpCacheSize 条时淘汰最久未使用的条目
+const (
+	httpCacheTTL    = 24 * time.Hour
+	httpNegativeTTL = 10 * time.Minute
+	httpCacheSize   = 10000
+	// 同时进行的后台查询上限，超出时本次直接视为未命中且不缓存
+	httpMaxInflight = 32
+)
+
+// httpWait 请求路径上等待第三方接口的最长时间，超时后查询在后台继续并写入缓存
+var httpWait = 500 * time.Millisecond
+
+type cachedLocation struct {
+	ip        string
+	loc       Location
+	ok        bool
+	expiresAt time.Time
+}
+
+var (
+	httpClient = &http.Client{Timeout: 5 * time.Second}
+	httpCache  = make(map[string]*list.Element)
+	httpLRU    = list.New() // 队首为最近使用
+	// httpInflight 正在后台查询的 IP，查询结束时关闭对应的 channel
+	httpInflight = make(map[string]chan struct{})
+	httpMu       sync.Mutex
+)
+
+// cacheGet 读取未过期的缓存并标记为最近使用，过期条目直接移除
+func cacheGet(ip string) (cachedLocation, bool) {
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	el, ok := httpCache[ip]
+	if !ok {
+		return cachedLocation{}, false
+	}
+	c := el.Value.(cachedLocation)
+	if !time.Now().Before(c.expiresAt) {
+		httpLRU.Remove(el)
+		delete(httpCache, ip)
+		return cachedLocation{}, false
+	}
+	httpLRU.MoveToFront(el)
+	return c, true
+}
+
+// cacheSet 写入缓存，超出容量时淘汰最久未使用的条目
+func cacheSet(c cachedLocation) {
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	if el, ok := httpCache[c.ip]; ok {
+		el.Value = c
+		httpLRU.MoveToFront(el)
+		return
+	}
+	httpCache[c.ip] = httpLRU.PushFront(c)
+	for httpLRU.Len() > httpCacheSize {
+		oldest := httpLRU.Back()
+		httpLRU.Remove(oldest)
+		delete(httpCache, oldest.Value.(cachedLocation).ip)
+	}
+}
+
+// provider 第三方地理位置接口及其字段映射
+type provider struct {
+	name    string
+	url     string
+	country string
+	city    string
+	region  string
+}
+
+var providers = []provider{
+	// ip-api.com 的免费接口只支持 HTTP
+	{"ip-api.com", "http://ip-api.com/json/%s", "country", "city", "regionName"},
+	{"ipinfo.io", "https://ipinfo.io/%s/json", "country", "city", "region"},
+	{"ipapi.co", "https://ipapi.co/%s/json/", "country_name", "city", "region"},
+}
+
+// lookupHTTP 查询第三方接口，结果按 IP 缓存
+// 查询在后台进行，调用方最多等待 httpWait，超时返回未命中，之后的请求使用缓存结果
+func lookupHTTP(ip string) (Location, bool) {
+	if c, ok := cacheGet(ip); ok {
+		return c.loc, c.ok
+	}
+
+	done, ok := startLookup(ip)
+	if !ok {
+		return Location{}, false
+	}
+	timer := time.NewTimer(httpWait)
+	defer timer.Stop()
+	select {
+	case <-done:
+		if c, ok := cacheGet(ip); ok {
+			return c.loc, c.ok
+		}
+	case <-timer.C:
+	}
+	return Location{}, false
+}
+
+// startLookup 在后台查询 ip，同一 IP 只发起一次，返回查询结束时关闭的 channel
+// 后台查询数达到上限时返回 false
+func startLookup(ip string) (<-chan struct{}, bool) {
+	httpMu.Lock()
+	defer httpMu.Unlock()
+	if done, ok := httpInflight[ip]; ok {
+		return done, true
+	}
+	if len(httpInflight) >= httpMaxInflight {
+		return nil, false
+	}
+	done := make(chan struct{})
+	httpInflight[ip] = done
+
+	go func() {
+		loc, ok := queryProviders(ip)
+		ttl := httpCacheTTL
+		if !ok {
+			ttl = httpNegativeTTL
+		}
+		cacheSet(cachedLocation{ip: ip, loc: loc, ok: ok, expiresAt: time.Now().Add(ttl)})
+
+		httpMu.Lock()
+		delete(httpInflight, ip)
+		httpMu.Unlock()
+		close(done)
+	}()
+	return done, true
+}
+
+// queryProviders 依次调用第三方接口，返回第一个命中的结果
+func queryProviders(ip string) (Location, bool) {
+	for _, p := range providers {
+		if loc, ok := queryProvider(p, ip); ok {
+			return loc, true
+		}
+	}
+	return Location{}, false
+}
+
+func queryProvider(p provider, ip string) (Location, bool) {
+	resp, err := httpClient.Get(fmt.Sprintf(p.url, ip))
+	if err != nil {
+		return Location{}, false
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Location{}, false
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Location{}, false
+	}
+
+	// 检查是否有错误（不同服务的错误字段不同）
+	if _, exists := data["error"]; exists {
+		return Location{}, false
+	}
+	if status, exists := data["status"]; exists && status != "success" {
+		return Location{}, false
+	}
+
+	loc := Location{
+		Country: getString(data, p.country),
+		City:    getString(data, p.city),
+		Region:  getString(data, p.region),
+	}
+	return loc, loc.Country != ""
+}
+
+// getString 从 map 中安全获取字符串值
+func getString(data map[string]interface{}, key string) string {
+	if value, exists := data[key]; exists {
+		if str, ok := value.(string); ok {
+			return str
+		}
+	}
+	return ""
+}
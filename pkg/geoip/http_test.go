@@ -0,0 +1,196 @@
+package geoip
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider 返回固定响应的第三方接口，记录被调用的次数
+func fakeProvider(t *testing.T, body string) (provider, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return provider{name: server.URL, url: server.URL + "/%s", country: "country", city: "city", region: "region"}, &hits
+}
+
+// useProviders 替换第三方接口并清空缓存，测试结束后恢复
+func useProviders(t *testing.T, ps ...provider) {
+	t.Helper()
+	original := providers
+	providers = ps
+	resetHTTPCache()
+	t.Cleanup(func() {
+		providers = original
+		resetHTTPCache()
+	})
+}
+
+func resetHTTPCache() {
+	httpMu.Lock()
+	httpCache = make(map[string]*list.Element)
+	httpLRU = list.New()
+	httpMu.Unlock()
+}
+
+func TestLookupHTTPFallsThroughProviders(t *testing.T) {
+	failing, failingHits := fakeProvider(t, `{"status":"fail","message":"reserved range"}`)
+	working, workingHits := fakeProvider(t, `{"country":"Japan","city":"Tokyo","region":"Tokyo"}`)
+	useProviders(t, failing, working)
+
+	want := Location{Country: "Japan", City: "Tokyo", Region: "Tokyo"}
+	for i := 0; i < 2; i++ {
+		loc, ok := lookupHTTP("203.0.113.7")
+		if !ok || loc != want {
+			t.Fatalf("lookupHTTP = %+v, %v, want %+v", loc, ok, want)
+		}
+	}
+	// 第二次命中缓存
+	if *failingHits != 1 || *workingHits != 1 {
+		t.Errorf("provider hits = %d, %d, want 1, 1", *failingHits, *workingHits)
+	}
+}
+
+func TestLookupHTTPNegativeCache(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"error field", `{"error":true,"reason":"Reserved IP Address"}`},
+		{"failed status", `{"status":"fail"}`},
+		{"no country", `{"city":"Tokyo"}`},
+		{"invalid json", `<html>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, hits := fakeProvider(t, tt.body)
+			useProviders(t, p)
+			for i := 0; i < 3; i++ {
+				if loc, ok := lookupHTTP("203.0.113.8"); ok {
+					t.Fatalf("lookupHTTP = %+v, want miss", loc)
+				}
+			}
+			if *hits != 1 {
+				t.Errorf("provider hits = %d, want 1 (miss should be cached)", *hits)
+			}
+		})
+	}
+}
+
+func TestLookupSkipsHTTPWhenDisabled(t *testing.T) {
+	p, hits := fakeProvider(t, `{"country":"Japan"}`)
+	useProviders(t, p)
+	SetHTTPFallback(true)
+	t.Cleanup(func() { SetHTTPFallback(false) })
+
+	for _, ip := range []string{"10.0.0.1", "127.0.0.1", "::1", "fe80::1", "203.0.113.9", "2001:db8::1", "not-an-ip"} {
+		if _, ok := Lookup(ip); ok {
+			t.Errorf("Lookup(%q) found a location", ip)
+		}
+	}
+	if *hits != 0 {
+		t.Errorf("provider hits = %d for reserved addresses, want 0", *hits)
+	}
+
+	SetHTTPFallback(false)
+	if _, ok := Lookup("8.8.8.8"); ok || *hits != 0 {
+		t.Errorf("Lookup called the HTTP provider with the fallback disabled")
+	}
+	SetHTTPFallback(true)
+	if loc, ok := Lookup("8.8.8.8"); !ok || loc.Country != "Japan" {
+		t.Errorf("Lookup = %+v, %v with the fallback enabled", loc, ok)
+	}
+}
+
+func TestLookupHTTPDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		fmt.Fprint(w, `{"country":"Japan"}`)
+	}))
+	t.Cleanup(server.Close)
+	useProviders(t, provider{name: server.URL, url: server.URL + "/%s", country: "country"})
+	original := httpWait
+	httpWait = 20 * time.Millisecond
+	t.Cleanup(func() { httpWait = original })
+
+	// 接口未返回前，调用方在 httpWait 后得到未命中，重复调用不会发起新的请求
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, ok := lookupHTTP("203.0.113.10"); ok {
+			t.Fatal("lookupHTTP found a location before the provider answered")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("lookupHTTP blocked for %v", elapsed)
+		}
+	}
+
+	httpMu.Lock()
+	done := httpInflight["203.0.113.10"]
+	httpMu.Unlock()
+	if done == nil {
+		t.Fatal("no background lookup in flight")
+	}
+	close(release)
+	<-done
+
+	// 后台查询完成后使用缓存结果
+	if loc, ok := lookupHTTP("203.0.113.10"); !ok || loc.Country != "Japan" {
+		t.Errorf("lookupHTTP after the background lookup = %+v, %v", loc, ok)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("provider hits = %d, want 1", n)
+	}
+}
+
+func TestHTTPCacheLRU(t *testing.T) {
+	resetHTTPCache()
+	t.Cleanup(resetHTTPCache)
+
+	expires := time.Now().Add(time.Hour)
+	for i := 0; i < httpCacheSize; i++ {
+		cacheSet(cachedLocation{ip: fmt.Sprint(i), ok: true, expiresAt: expires})
+	}
+	// 读取后成为最近使用，不会被淘汰
+	if _, ok := cacheGet("0"); !ok {
+		t.Fatal("entry 0 missing before eviction")
+	}
+	cacheSet(cachedLocation{ip: "new", ok: true, expiresAt: expires})
+
+	if len(httpCache) != httpCacheSize || httpLRU.Len() != httpCacheSize {
+		t.Errorf("cache size = %d/%d, want %d", len(httpCache), httpLRU.Len(), httpCacheSize)
+	}
+	if _, ok := cacheGet("1"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, ip := range []string{"0", "2", "new"} {
+		if _, ok := cacheGet(ip); !ok {
+			t.Errorf("entry %s was evicted", ip)
+		}
+	}
+
+	// 更新已有条目不增加容量
+	cacheSet(cachedLocation{ip: "new", loc: Location{Country: "Japan"}, ok: true, expiresAt: expires})
+	if c, _ := cacheGet("new"); c.loc.Country != "Japan" || httpLRU.Len() != httpCacheSize {
+		t.Errorf("updated entry = %+v, size = %d", c, httpLRU.Len())
+	}
+
+	// 过期条目读取时移除
+	cacheSet(cachedLocation{ip: "old", ok: true, expiresAt: time.Now().Add(-time.Second)})
+	if _, ok := cacheGet("old"); ok {
+		t.Error("expired entry was returned")
+	}
+	if _, ok := httpCache["old"]; ok {
+		t.Error("expired entry was not removed")
+	}
+}
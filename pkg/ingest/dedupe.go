@@ -0,0 +1,61 @@
+package ingest
+
+import (
+	"sync"
+	"time"
+)
+
+// Dedupe 按天去重的内存集合，日期变化时自动清空
+// 集合达到容量上限后不再记录新的 key，调用方需回退到数据库判断
+type Dedupe struct {
+	max  int
+	loc  *time.Location
+	date string
+	seen map[string]struct{}
+	mu   sync.Mutex
+}
+
+// NewDedupe 创建容量为 max 的去重集合，按 loc 时区的日期清空
+func NewDedupe(max int, loc *time.Location) *Dedupe {
+	return &Dedupe{max: max, loc: loc, seen: make(map[string]struct{})}
+}
+
+// Add 记录今天出现的 key，返回 key 此前是否已出现过；集合已满且 key 未出现过时 full 为 true
+func (d *Dedupe) Add(key string) (seen bool, full bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reset()
+	if _, ok := d.seen[key]; ok {
+		return true, false
+	}
+	if d.max > 0 && len(d.seen) >= d.max {
+		return false, true
+	}
+	d.seen[key] = struct{}{}
+	return false, false
+}
+
+// Remove 撤销 key 的记录，用于数据写入失败被丢弃的情况
+func (d *Dedupe) Remove(key string) {
+	d.mu.Lock()
+	delete(d.seen, key)
+	d.mu.Unlock()
+}
+
+// Len 今天已记录的 key 数量
+func (d *Dedupe) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reset()
+	return len(d.seen)
+}
+
+// reset 日期变化时清空集合，调用方需持有 mu
+func (d *Dedupe) reset() {
+	today := time.Now().In(d.loc).Format("2006-01-02")
+	if d.date != today {
+		d.date = today
+		d.seen = make(map[string]struct{})
+	}
+}
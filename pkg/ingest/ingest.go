@@ -0,0 +1,261 @@
+package ingest
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 队列满或写入失败时的处理方式
+const (
+	OverflowDrop  = "drop"  // 丢弃
+	OverflowSpool = "spool" // 追加到本地文件，数据库恢复后回放
+)
+
+// ErrCloseTimeout Close 在超时前未能写完队列中的数据
+var ErrCloseTimeout = errors.New("ingest: close timed out")
+
+// Config 写入队列配置
+type Config struct {
+	QueueSize     int           // 队列容量，超出后按 Overflow 处理
+	BatchSize     int           // 单次批量写入的最大条数
+	FlushInterval time.Duration // 不足一批时的最长等待时间
+	Overflow      string        // drop 或 spool
+	SpoolDir      string        // spool 文件所在目录
+	SpoolMaxBytes int64         // spool 文件大小上限，超出后丢弃
+	Ping          func() error  // 可选，批量写入失败时判断数据库是否可用
+}
+
+var (
+	eventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingest_events_total",
+			Help: "Number of events handled by the ingestion queue, by outcome",
+		},
+		[]string{"queue", "result"},
+	)
+
+	flushErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingest_flush_errors_total",
+			Help: "Number of failed batch writes",
+		},
+		[]string{"queue"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(eventsTotal)
+	prometheus.MustRegister(flushErrorsTotal)
+}
+
+// Queue 进程内的异步写入队列：请求路径只入队，后台按批量或时间间隔调用 flush 写入
+// 内存占用受 QueueSize 限制；flush 失败（如数据库不可用）时按 Overflow 丢弃或写入 spool 文件
+type Queue[T any] struct {
+	name  string
+	cfg   Config
+	flush func([]T) error
+	spool *spool[T]
+
+	ch     chan T
+	mu     sync.RWMutex
+	closed bool
+	once   sync.Once
+	done   chan struct{}
+}
+
+// New 创建并启动队列，name 用于指标标签和 spool 文件名
+func New[T any](name string, cfg Config, flush func([]T) error) *Queue[T] {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	q := &Queue[T]{
+		name:  name,
+		cfg:   cfg,
+		flush: flush,
+		ch:    make(chan T, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+	if cfg.Overflow == OverflowSpool {
+		q.spool = newSpool[T](cfg.SpoolDir, name, cfg.SpoolMaxBytes)
+	}
+
+	prometheus.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name:        "ingest_queue_depth",
+			Help:        "Number of events waiting in the ingestion queue",
+			ConstLabels: prometheus.Labels{"queue": name},
+		},
+		func() float64 { return float64(len(q.ch)) },
+	))
+
+	go q.run()
+	return q
+}
+
+// Submit 入队，返回 false 表示被丢弃
+// 队列已关闭（进程退出中）时改为同步写入
+func (q *Queue[T]) Submit(item T) bool {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return q.write([]T{item})
+	}
+	select {
+	case q.ch <- item:
+		q.mu.RUnlock()
+		q.count("queued", 1)
+		return true
+	default:
+		q.mu.RUnlock()
+	}
+	return q.overflow([]T{item})
+}
+
+// Close 停止接收新数据并写完队列中剩余的数据，可重复调用
+func (q *Queue[T]) Close(timeout time.Duration) error {
+	q.once.Do(func() {
+		q.mu.Lock()
+		q.closed = true
+		close(q.ch)
+		q.mu.Unlock()
+	})
+	select {
+	case <-q.done:
+		return nil
+	case <-time.After(timeout):
+		return ErrCloseTimeout
+	}
+}
+
+func (q *Queue[T]) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, q.cfg.BatchSize)
+	for {
+		select {
+		case item, ok := <-q.ch:
+			if !ok {
+				q.write(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) < q.cfg.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				q.replay()
+				continue
+			}
+		}
+		if q.write(batch) {
+			q.replay()
+		}
+		batch = make([]T, 0, q.cfg.BatchSize)
+	}
+}
+
+// write 写入一批数据，失败时逐条重试，数据库不可用时按 Overflow 处理，返回数据库是否可用
+func (q *Queue[T]) write(batch []T) bool {
+	if len(batch) == 0 {
+		return true
+	}
+	if err := q.flush(batch); err != nil {
+		flushErrorsTotal.WithLabelValues(q.name).Inc()
+		log.Printf("写入 %s 失败（%d 条）: %v", q.name, len(batch), err)
+		written, failed, available := q.retry(batch)
+		q.count("inserted", written)
+		if !available {
+			q.overflow(failed)
+			return false
+		}
+		return true
+	}
+	q.count("inserted", len(batch))
+	return true
+}
+
+// retry 批量写入失败后逐条写入，避免一条无效数据（如超长字段）拖累整批
+// 数据库可用（有数据写入成功或 Ping 正常）时无法写入的数据单独丢弃，不再进入 spool 反复回放；
+// 否则返回全部未写入的数据，由调用方按 Overflow 处理
+func (q *Queue[T]) retry(batch []T) (written int, failed []T, available bool) {
+	if q.cfg.Ping != nil && q.cfg.Ping() != nil {
+		return 0, batch, false
+	}
+	if len(batch) > 1 {
+		for _, item := range batch {
+			if err := q.flush([]T{item}); err != nil {
+				failed = append(failed, item)
+				continue
+			}
+			written++
+		}
+	} else {
+		failed = batch
+	}
+	available = written > 0 || q.cfg.Ping != nil
+	if available && len(failed) > 0 {
+		log.Printf("丢弃 %s 中无法写入的 %d 条数据", q.name, len(failed))
+		q.count("dropped", len(failed))
+	}
+	return written, failed, available
+}
+
+// overflow 队列满或写入失败时丢弃或写入 spool 文件，返回是否保留了数据
+func (q *Queue[T]) overflow(items []T) bool {
+	if q.spool != nil {
+		err := q.spool.append(items)
+		if err == nil {
+			q.count("spooled", len(items))
+			return true
+		}
+		log.Printf("写入 %s spool 失败: %v", q.name, err)
+	}
+	q.count("dropped", len(items))
+	return false
+}
+
+// replay 数据库恢复后回放 spool 文件，回放失败的数据重新写回 spool
+func (q *Queue[T]) replay() {
+	if q.spool == nil {
+		return
+	}
+	items, err := q.spool.take()
+	if err != nil {
+		log.Printf("读取 %s spool 失败: %v", q.name, err)
+		return
+	}
+	for len(items) > 0 {
+		n := min(len(items), q.cfg.BatchSize)
+		if err := q.flush(items[:n]); err != nil {
+			flushErrorsTotal.WithLabelValues(q.name).Inc()
+			written, failed, available := q.retry(items[:n])
+			q.count("replayed", written)
+			if !available {
+				q.overflow(append(failed, items[n:]...))
+				return
+			}
+		} else {
+			q.count("replayed", n)
+		}
+		items = items[n:]
+	}
+}
+
+func (q *Queue[T]) count(result string, n int) {
+	eventsTotal.WithLabelValues(q.name, result).Add(float64(n))
+}
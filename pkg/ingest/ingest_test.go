@@ -0,0 +1,225 @@
+package ingest
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// store 模拟数据库：记录写入的数据，可切换为不可用或拒绝特定的数据
+type store struct {
+	mu     sync.Mutex
+	rows   []int
+	calls  int
+	down   bool
+	poison map[int]bool
+}
+
+func (s *store) flush(batch []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.down {
+		return errors.New("database is down")
+	}
+	for _, v := range batch {
+		if s.poison[v] {
+			return errors.New("value too long")
+		}
+	}
+	s.rows = append(s.rows, batch...)
+	return nil
+}
+
+func (s *store) ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("database is down")
+	}
+	return nil
+}
+
+func (s *store) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *store) written() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := append([]int(nil), s.rows...)
+	sort.Ints(rows)
+	return rows
+}
+
+func TestQueueBatches(t *testing.T) {
+	s := &store{}
+	q := New("test_batches", Config{BatchSize: 3, FlushInterval: time.Hour}, s.flush)
+	for i := 1; i <= 7; i++ {
+		if !q.Submit(i) {
+			t.Fatalf("Submit(%d) dropped", i)
+		}
+	}
+	if err := q.Close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(s.written(), want) {
+		t.Errorf("written = %v, want %v", s.written(), want)
+	}
+	// 两个满批加上 Close 时剩余的一批
+	if s.calls != 3 {
+		t.Errorf("flush calls = %d, want 3", s.calls)
+	}
+
+	// 关闭后改为同步写入
+	if !q.Submit(8) || len(s.written()) != 8 {
+		t.Errorf("Submit after Close was not written: %v", s.written())
+	}
+}
+
+func TestQueueRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		batch   []int
+		poison  map[int]bool
+		down    bool
+		ping    bool
+		want    []int
+		spooled []int
+	}{
+		{
+			name:   "poison row is dropped alone",
+			batch:  []int{1, 2, 3},
+			poison: map[int]bool{2: true},
+			want:   []int{1, 3},
+		},
+		{
+			name:   "single poison row with ping is dropped",
+			batch:  []int{2},
+			poison: map[int]bool{2: true},
+			ping:   true,
+		},
+		{
+			name:    "single failed row without ping is spooled",
+			batch:   []int{2},
+			poison:  map[int]bool{2: true},
+			spooled: []int{2},
+		},
+		{
+			name:    "database down spools the whole batch",
+			batch:   []int{1, 2, 3},
+			down:    true,
+			ping:    true,
+			spooled: []int{1, 2, 3},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &store{poison: tt.poison, down: tt.down}
+			cfg := Config{BatchSize: 10, FlushInterval: time.Hour, Overflow: OverflowSpool, SpoolDir: t.TempDir()}
+			if tt.ping {
+				cfg.Ping = s.ping
+			}
+			q := New("test_retry_"+string(rune('a'+i)), cfg, s.flush)
+			if q.write(tt.batch) != (tt.spooled == nil) {
+				t.Errorf("write reported the wrong availability")
+			}
+			if got := s.written(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("written = %v, want %v", got, tt.want)
+			}
+			spooled, err := q.spool.take()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(spooled, tt.spooled) {
+				t.Errorf("spooled = %v, want %v", spooled, tt.spooled)
+			}
+			q.Close(time.Second)
+		})
+	}
+}
+
+func TestQueueSpoolReplay(t *testing.T) {
+	s := &store{down: true}
+	cfg := Config{BatchSize: 2, FlushInterval: time.Hour, Overflow: OverflowSpool, SpoolDir: t.TempDir(), Ping: s.ping}
+	q := New("test_replay", cfg, s.flush)
+	defer q.Close(time.Second)
+
+	if q.write([]int{1, 2}) {
+		t.Fatal("write succeeded while the database was down")
+	}
+	if q.write([]int{3}) {
+		t.Fatal("write succeeded while the database was down")
+	}
+	if len(s.written()) != 0 {
+		t.Fatalf("written = %v while the database was down", s.written())
+	}
+
+	// 数据库仍不可用时回放的数据重新写回 spool
+	q.replay()
+	if len(s.written()) != 0 {
+		t.Fatalf("written = %v while the database was down", s.written())
+	}
+
+	s.setDown(false)
+	q.replay()
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(s.written(), want) {
+		t.Errorf("written = %v, want %v", s.written(), want)
+	}
+	if items, _ := q.spool.take(); len(items) != 0 {
+		t.Errorf("spool not emptied after replay: %v", items)
+	}
+}
+
+func TestQueueOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow string
+		maxBytes int64
+		want     bool
+	}{
+		{"drop", OverflowDrop, 0, false},
+		{"spool", OverflowSpool, 0, true},
+		{"spool full", OverflowSpool, 1, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Overflow: tt.overflow, SpoolDir: t.TempDir(), SpoolMaxBytes: tt.maxBytes}
+			q := New("test_overflow_"+string(rune('a'+i)), cfg, (&store{}).flush)
+			defer q.Close(time.Second)
+			if got := q.overflow([]int{1, 2}); got != tt.want {
+				t.Errorf("overflow = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	d := NewDedupe(2, time.UTC)
+	steps := []struct {
+		key  string
+		seen bool
+		full bool
+	}{
+		{"a", false, false},
+		{"a", true, false},
+		{"b", false, false},
+		{"c", false, true},
+		{"b", true, false},
+	}
+	for _, st := range steps {
+		seen, full := d.Add(st.key)
+		if seen != st.seen || full != st.full {
+			t.Errorf("Add(%q) = %v, %v, want %v, %v", st.key, seen, full, st.seen, st.full)
+		}
+	}
+	d.Remove("a")
+	if d.Len() != 1 {
+		t.Errorf("Len = %d, want 1", d.Len())
+	}
+}
@@ -0,0 +1,98 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// errSpoolFull spool 文件已达到大小上限
+var errSpoolFull = errors.New("spool file is full")
+
+// spool 以 JSON Lines 格式把暂时无法写入数据库的数据追加到本地文件
+type spool[T any] struct {
+	path     string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+func newSpool[T any](dir, name string, maxBytes int64) *spool[T] {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return &spool[T]{path: filepath.Join(dir, name+".spool"), maxBytes: maxBytes}
+}
+
+// append 追加数据，超过大小上限时整批拒绝
+func (s *spool[T]) append(items []T) error {
+	var buf []byte
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if s.maxBytes > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size()+int64(len(buf)) > s.maxBytes {
+			return errSpoolFull
+		}
+	}
+	_, err = f.Write(buf)
+	return err
+}
+
+// take 读出并清空 spool 文件中的全部数据；无法解析的行会被跳过
+func (s *spool[T]) take() ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []T
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item T
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+	if err := os.Remove(s.path); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
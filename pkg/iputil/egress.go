@@ -0,0 +1,78 @@
+package iputil
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 服务器出口 IP 缓存，由后台定时刷新，请求路径上只读缓存
+var (
+	egressIP     string
+	egressMu     sync.RWMutex
+	egressClient = &http.Client{Timeout: 5 * time.Second}
+)
+
+// EgressIP 返回缓存的服务器出口公网 IP，尚未获取到时返回空字符串
+func EgressIP() string {
+	egressMu.RLock()
+	defer egressMu.RUnlock()
+	return egressIP
+}
+
+// SetEgressIP 直接设置出口 IP（用于静态配置）
+func SetEgressIP(ip string) {
+	egressMu.Lock()
+	egressIP = Normalize(ip)
+	egressMu.Unlock()
+}
+
+// StartEgressRefresher 立即在后台获取一次出口 IP，之后按 interval 定时刷新
+// 依次尝试 urls，取第一个返回合法公网 IP 的服务；全部失败时保留上一次的结果
+func StartEgressRefresher(urls []string, interval time.Duration) {
+	if len(urls) == 0 {
+		return
+	}
+	go func() {
+		refreshEgressIP(urls)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshEgressIP(urls)
+		}
+	}()
+}
+
+func refreshEgressIP(urls []string) {
+	for _, url := range urls {
+		if ip := fetchIP(url); ip != "" {
+			SetEgressIP(ip)
+			return
+		}
+	}
+	log.Printf("获取服务器出口 IP 失败，继续使用缓存: %q", EgressIP())
+}
+
+func fetchIP(url string) string {
+	resp, err := egressClient.Get(url)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 128))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	ip := strings.TrimSpace(string(body))
+	if !IsPublic(ip) {
+		return ""
+	}
+	return ip
+}
@@ -0,0 +1,152 @@
+package iputil
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// 不可路由到公网的地址段（私有、回环、链路本地、保留、文档、组播等）
+var reservedPrefixes = mustPrefixes(
+	// IPv4
+	"0.0.0.0/8",          // 本网络
+	"10.0.0.0/8",         // 私有
+	"100.64.0.0/10",      // 运营商级 NAT
+	"127.0.0.0/8",        // 回环
+	"169.254.0.0/16",     // 链路本地
+	"172.16.0.0/12",      // 私有
+	"192.0.0.0/24",       // IETF 协议分配
+	"192.0.2.0/24",       // 文档 TEST-NET-1
+	"192.88.99.0/24",     // 6to4 中继（已废弃）
+	"192.168.0.0/16",     // 私有
+	"198.18.0.0/15",      // 基准测试
+	"198.51.100.0/24",    // 文档 TEST-NET-2
+	"203.0.113.0/24",     // 文档 TEST-NET-3
+	"224.0.0.0/4",        // 组播
+	"240.0.0.0/4",        // 保留
+	"255.255.255.255/32", // 广播
+	// IPv6
+	"::/128",        // 未指定
+	"::1/128",       // 回环
+	"100::/64",      // 丢弃
+	"2001:db8::/32", // 文档
+	"fc00::/7",      // 唯一本地地址
+	"fe80::/10",     // 链路本地
+	"ff00::/8",      // 组播
+)
+
+func mustPrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}
+
+// Parse 解析 IP 地址，兼容 "1.2.3.4:80"、"[::1]:80"、带引号和 zone 的写法
+// IPv4 映射的 IPv6 地址（::ffff:1.2.3.4）会转换为 IPv4
+func Parse(s string) (netip.Addr, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.WithZone("").Unmap(), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().WithZone("").Unmap(), true
+	}
+	// 带方括号但没有端口的 IPv6
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		if addr, err := netip.ParseAddr(s[1 : len(s)-1]); err == nil {
+			return addr.WithZone("").Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// IsValid 是否为合法的 IPv4/IPv6 地址
+func IsValid(s string) bool {
+	_, ok := Parse(s)
+	return ok
+}
+
+// Normalize 返回规范化的地址字符串，无法解析时返回空字符串
+func Normalize(s string) string {
+	addr, ok := Parse(s)
+	if !ok {
+		return ""
+	}
+	return addr.String()
+}
+
+// IsReserved 是否为私有或保留地址
+func IsReserved(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivate 字符串形式的 IsReserved，无法解析的地址视为非公网地址
+func IsPrivate(s string) bool {
+	addr, ok := Parse(s)
+	return !ok || IsReserved(addr)
+}
+
+// IsPublic 是否为可路由到公网的地址
+func IsPublic(s string) bool {
+	addr, ok := Parse(s)
+	return ok && !IsReserved(addr)
+}
+
+// SplitList 拆分 X-Forwarded-For 等逗号分隔的地址列表，保留原有顺序
+func SplitList(header string) []string {
+	var result []string
+	for _, part := range strings.Split(header, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// ParseForwarded 解析 RFC 7239 Forwarded 头，按顺序返回各节点的 for= 值
+// 例如 `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`
+// 混淆标识（for=unknown、for=_hidden）原样返回，由调用方校验
+func ParseForwarded(header string) []string {
+	var result []string
+	for _, element := range splitQuoted(header, ',') {
+		for _, pair := range splitQuoted(element, ';') {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "for") {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			if value != "" {
+				result = append(result, value)
+			}
+		}
+	}
+	return result
+}
+
+// splitQuoted 按分隔符拆分字符串，忽略引号内的分隔符
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == sep && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,86 @@
+package iputil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"1.2.3.4", "1.2.3.4", true},
+		{" 1.2.3.4:8080 ", "1.2.3.4", true},
+		{`"1.2.3.4"`, "1.2.3.4", true},
+		{"::ffff:1.2.3.4", "1.2.3.4", true},
+		{"[2001:db8::1]:443", "2001:db8::1", true},
+		{"[2001:db8::1]", "2001:db8::1", true},
+		{"fe80::1%eth0", "fe80::1", true},
+		{"", "", false},
+		{"unknown", "", false},
+		{"1.2.3.256", "", false},
+	}
+	for _, tt := range tests {
+		addr, ok := Parse(tt.in)
+		if ok != tt.ok {
+			t.Errorf("Parse(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && addr.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, addr, tt.want)
+		}
+	}
+}
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{`for=192.0.2.60;proto=http;by=203.0.113.43`, []string{"192.0.2.60"}},
+		{`for=192.0.2.43, for="[2001:db8:cafe::17]:4711"`, []string{"192.0.2.43", "[2001:db8:cafe::17]:4711"}},
+		{`For="_hidden", for=unknown`, []string{"_hidden", "unknown"}},
+		{`proto=https;host="a,b"`, nil},
+	}
+	for _, tt := range tests {
+		if got := ParseForwarded(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseForwarded(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"fd00::1", false},
+		{"::1", false},
+		{"garbage", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(tt.ip); got != tt.want {
+			t.Errorf("IsPublic(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+		if got := IsPrivate(tt.ip); got == tt.want {
+			t.Errorf("IsPrivate(%q) = %v, want %v", tt.ip, got, !tt.want)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := SplitList(" 1.1.1.1 ,, 2.2.2.2,")
+	if want := []string{"1.1.1.1", "2.2.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitList = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package iputil
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// Resolver 根据可信代理配置解析客户端 IP
+// 只有直连对端属于可信代理时才读取请求头；X-Forwarded-For 和 Forwarded
+// 从右往左逐跳检查，遇到第一个不可信的地址即为客户端 IP
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+var (
+	defaultResolver = &Resolver{}
+	resolverMu      sync.RWMutex
+)
+
+// NewResolver 创建解析器，trustedCIDRs 为可信代理地址段（单个 IP 视为 /32 或 /128），
+// headers 为按优先级排列的客户端 IP 请求头
+func NewResolver(trustedCIDRs, headers []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, cidr := range trustedCIDRs {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	for _, h := range headers {
+		r.headers = append(r.headers, http.CanonicalHeaderKey(strings.TrimSpace(h)))
+	}
+	return r, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Addr().Is4In6() {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked(), nil
+}
+
+// SetResolver 替换默认解析器
+func SetResolver(r *Resolver) {
+	resolverMu.Lock()
+	defaultResolver = r
+	resolverMu.Unlock()
+}
+
+// ClientIP 使用默认解析器获取客户端 IP
+func ClientIP(req *http.Request) string {
+	resolverMu.RLock()
+	r := defaultResolver
+	resolverMu.RUnlock()
+	return r.ClientIP(req)
+}
+
+// Trusted 地址是否属于可信代理
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP 获取客户端 IP，无法从请求头得到时返回直连对端地址
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote := remoteAddr(req)
+	if !remote.IsValid() {
+		return ""
+	}
+	if !r.Trusted(remote) {
+		return remote.String()
+	}
+
+	for _, header := range r.headers {
+		values := req.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		var chain []string
+		switch header {
+		case "X-Forwarded-For":
+			chain = SplitList(strings.Join(values, ","))
+		case "Forwarded":
+			chain = ParseForwarded(strings.Join(values, ","))
+		default:
+			// 单值请求头由可信代理直接设置
+			if addr, ok := Parse(values[0]); ok {
+				return addr.String()
+			}
+			continue
+		}
+
+		if ip, ok := r.walk(chain); ok {
+			return ip
+		}
+	}
+	return remote.String()
+}
+
+// walk 从右往左跳过可信代理，返回第一个不可信的地址；
+// 遇到无法解析的地址时放弃该请求头，全部可信时返回最左侧的地址
+func (r *Resolver) walk(chain []string) (string, bool) {
+	var leftmost netip.Addr
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := Parse(chain[i])
+		if !ok {
+			return "", false
+		}
+		if !r.Trusted(addr) {
+			return addr.String(), true
+		}
+		leftmost = addr
+	}
+	if leftmost.IsValid() {
+		return leftmost.String(), true
+	}
+	return "", false
+}
+
+func remoteAddr(req *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(req.RemoteAddr))
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, _ := Parse(host)
+	return addr
+}
@@ -0,0 +1,90 @@
+package iputil
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolverClientIP(t *testing.T) {
+	resolver, err := NewResolver(
+		[]string{"10.0.0.0/8", "127.0.0.1", "::1"},
+		[]string{"CF-Connecting-IP", "x-forwarded-for", "Forwarded"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:    "untrusted peer ignores headers",
+			remote:  "203.0.113.9:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.1.1.1"}, "Cf-Connecting-Ip": {"2.2.2.2"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "single-value header from trusted peer",
+			remote:  "10.0.0.2:5000",
+			headers: map[string][]string{"Cf-Connecting-Ip": {"2.2.2.2"}, "X-Forwarded-For": {"1.1.1.1"}},
+			want:    "2.2.2.2",
+		},
+		{
+			name:    "xff walks right to left past trusted hops",
+			remote:  "10.0.0.2:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"6.6.6.6, 1.1.1.1, 10.0.0.5"}},
+			want:    "1.1.1.1",
+		},
+		{
+			name:    "xff across multiple header lines",
+			remote:  "10.0.0.2:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"6.6.6.6", "1.1.1.1", "10.0.0.5"}},
+			want:    "1.1.1.1",
+		},
+		{
+			name:    "all hops trusted uses leftmost",
+			remote:  "127.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"10.1.1.1, 10.0.0.5"}},
+			want:    "10.1.1.1",
+		},
+		{
+			name:    "invalid xff entry falls through to next header",
+			remote:  "10.0.0.2:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.1.1.1, garbage"}, "Forwarded": {`for="[2001:db8::1]:80"`}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "invalid single-value header is skipped",
+			remote:  "10.0.0.2:5000",
+			headers: map[string][]string{"Cf-Connecting-Ip": {"nope"}, "X-Forwarded-For": {"1.1.1.1"}},
+			want:    "1.1.1.1",
+		},
+		{
+			name:   "no headers returns peer",
+			remote: "[::1]:5000",
+			want:   "::1",
+		},
+		{
+			name:   "invalid remote",
+			remote: "",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remote, Header: http.Header(tt.headers)}
+			if got := resolver.ClientIP(req); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolverInvalidCIDR(t *testing.T) {
+	if _, err := NewResolver([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("NewResolver accepted an invalid CIDR")
+	}
+}
@@ -0,0 +1,185 @@
+package live
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认的在线判定窗口和按分钟统计的时长
+const (
+	DefaultWindow  = 5 * time.Minute
+	DefaultMinutes = 30
+)
+
+// Snapshot 某一时刻的实时访客快照
+type Snapshot struct {
+	Visitors  int           `json:"visitors"` // 在线访客（窗口内有访问或心跳的会话）
+	Pages     []PageCount   `json:"pages"`    // 在线访客所在的页面，按人数降序
+	Minutes   []MinuteCount `json:"minutes"`  // 最近每分钟的访问量，按时间升序，包含当前分钟
+	UpdatedAt string        `json:"updated_at"`
+}
+
+// PageCount 页面的在线访客数
+type PageCount struct {
+	Page     string `json:"page"`
+	Visitors int    `json:"visitors"`
+}
+
+// MinuteCount 一分钟内的访问量
+type MinuteCount struct {
+	Time   string `json:"time"` // 该分钟的开始时间，YYYY-MM-DD HH:MM
+	Visits int    `json:"visits"`
+}
+
+type presence struct {
+	page     string
+	lastSeen time.Time
+}
+
+type minute struct {
+	start  int64 // Unix 分钟数
+	visits int
+}
+
+// Tracker 进程内的实时访客统计，只保存窗口内的会话和最近几分钟的访问量
+// 会话数超过 maxVisitors 时不再记录新会话，已在线的会话照常更新
+type Tracker struct {
+	window      time.Duration
+	maxVisitors int
+
+	mu       sync.Mutex
+	visitors map[string]presence
+	minutes  []minute // 按 Unix 分钟数取模的环形缓冲区
+	changed  chan struct{}
+}
+
+// NewTracker 创建实时访客统计，window 为在线判定窗口，minutes 为保留的分钟数
+func NewTracker(window time.Duration, minutes, maxVisitors int) *Tracker {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if minutes <= 0 {
+		minutes = DefaultMinutes
+	}
+	return &Tracker{
+		window:      window,
+		maxVisitors: maxVisitors,
+		visitors:    make(map[string]presence),
+		minutes:     make([]minute, minutes),
+		changed:     make(chan struct{}),
+	}
+}
+
+// Visit 记录一次页面访问：计入当前分钟的访问量并更新会话所在页面
+func (t *Tracker) Visit(sessionID, page string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	m := now.Unix() / 60
+	slot := &t.minutes[m%int64(len(t.minutes))]
+	if slot.start != m {
+		*slot = minute{start: m}
+	}
+	slot.visits++
+	t.touch(sessionID, page, now)
+	t.notify()
+}
+
+// Touch 记录会话仍在页面上（如阅读心跳），不计入访问量
+// 只刷新 Visit 记录过且仍在窗口内的会话，不会新增会话
+func (t *Tracker) Touch(sessionID, page string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	p, ok := t.visitors[sessionID]
+	if !ok || now.Sub(p.lastSeen) > t.window {
+		return
+	}
+	t.visitors[sessionID] = presence{page: page, lastSeen: now}
+	if p.page != page {
+		t.notify()
+	}
+}
+
+// Changed 返回在下一次数据变化时关闭的 channel，用于推送更新
+func (t *Tracker) Changed() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.changed
+}
+
+// Snapshot 返回当前在线访客、人数最多的 limit 个页面和最近每分钟的访问量，时间按 loc 时区显示
+func (t *Tracker) Snapshot(limit int, loc *time.Location) Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().In(loc)
+	t.expire(now)
+
+	counts := make(map[string]int)
+	for _, p := range t.visitors {
+		counts[p.page]++
+	}
+	pages := make([]PageCount, 0, len(counts))
+	for page, n := range counts {
+		pages = append(pages, PageCount{Page: page, Visitors: n})
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Visitors != pages[j].Visitors {
+			return pages[i].Visitors > pages[j].Visitors
+		}
+		return pages[i].Page < pages[j].Page
+	})
+	if limit > 0 && len(pages) > limit {
+		pages = pages[:limit]
+	}
+
+	current := now.Unix() / 60
+	minutes := make([]MinuteCount, 0, len(t.minutes))
+	for m := current - int64(len(t.minutes)) + 1; m <= current; m++ {
+		mc := MinuteCount{Time: time.Unix(m*60, 0).In(loc).Format("2006-01-02 15:04")}
+		if slot := t.minutes[m%int64(len(t.minutes))]; slot.start == m {
+			mc.Visits = slot.visits
+		}
+		minutes = append(minutes, mc)
+	}
+
+	return Snapshot{
+		Visitors:  len(t.visitors),
+		Pages:     pages,
+		Minutes:   minutes,
+		UpdatedAt: now.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// touch 记录会话的页面和最后活跃时间；调用方需持有 mu
+func (t *Tracker) touch(sessionID, page string, now time.Time) {
+	if sessionID == "" {
+		return
+	}
+	if _, ok := t.visitors[sessionID]; !ok && t.maxVisitors > 0 && len(t.visitors) >= t.maxVisitors {
+		t.expire(now)
+		if len(t.visitors) >= t.maxVisitors {
+			return
+		}
+	}
+	t.visitors[sessionID] = presence{page: page, lastSeen: now}
+}
+
+// expire 移除超出在线窗口的会话；调用方需持有 mu
+func (t *Tracker) expire(now time.Time) {
+	for id, p := range t.visitors {
+		if now.Sub(p.lastSeen) > t.window {
+			delete(t.visitors, id)
+		}
+	}
+}
+
+// notify 唤醒等待 Changed 的订阅者；调用方需持有 mu
+func (t *Tracker) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,95 @@
+package live
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// totalVisits 汇总快照中每分钟的访问量
+func totalVisits(s Snapshot) int {
+	n := 0
+	for _, m := range s.Minutes {
+		n += m.Visits
+	}
+	return n
+}
+
+func TestTrackerVisitAndTouch(t *testing.T) {
+	tr := NewTracker(time.Minute, 5, 0)
+	tr.Visit("s1", "/a/")
+	tr.Visit("s2", "/a/")
+	tr.Visit("s3", "/b/")
+	tr.Visit("", "/c/") // 没有会话时只计入访问量
+	tr.Touch("s3", "/a/")
+
+	s := tr.Snapshot(10, time.Local)
+	if s.Visitors != 3 {
+		t.Errorf("visitors = %d, want 3", s.Visitors)
+	}
+	if want := []PageCount{{"/a/", 3}}; !reflect.DeepEqual(s.Pages, want) {
+		t.Errorf("pages = %+v, want %+v", s.Pages, want)
+	}
+	if len(s.Minutes) != 5 || totalVisits(s) != 4 {
+		t.Errorf("minutes = %+v, want 5 minutes with 4 visits", s.Minutes)
+	}
+
+	tr.Touch("s1", "/b/")
+	if got := tr.Snapshot(1, time.Local).Pages; !reflect.DeepEqual(got, []PageCount{{"/a/", 2}}) {
+		t.Errorf("limited pages = %+v", got)
+	}
+
+	// 心跳不会新增会话
+	tr.Touch("s4", "/b/")
+	tr.Touch("", "/b/")
+	if s := tr.Snapshot(10, time.Local); s.Visitors != 3 || totalVisits(s) != 4 {
+		t.Errorf("after touching unknown sessions = %+v, want 3 visitors", s)
+	}
+}
+
+func TestTrackerExpiresAndCaps(t *testing.T) {
+	tr := NewTracker(20*time.Millisecond, 1, 2)
+	tr.Visit("s1", "/a/")
+	tr.Visit("s2", "/a/")
+	// 达到上限后不再记录新会话，已在线的会话照常更新
+	tr.Visit("s3", "/a/")
+	tr.Touch("s2", "/b/")
+	if s := tr.Snapshot(10, time.Local); s.Visitors != 2 || totalVisits(s) != 3 {
+		t.Errorf("capped snapshot = %+v", s)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// 超出窗口的会话不会被心跳重新激活
+	tr.Touch("s1", "/a/")
+	tr.Visit("s3", "/c/")
+	s := tr.Snapshot(10, time.Local)
+	if want := []PageCount{{"/c/", 1}}; s.Visitors != 1 || !reflect.DeepEqual(s.Pages, want) {
+		t.Errorf("after expiry = %+v, want only s3", s)
+	}
+}
+
+func TestTrackerChanged(t *testing.T) {
+	tr := NewTracker(time.Minute, 1, 0)
+	ch := tr.Changed()
+	tr.Visit("s1", "/a/")
+	select {
+	case <-ch:
+	default:
+		t.Fatal("Changed was not closed after Visit")
+	}
+
+	// 页面未变化的心跳不推送更新
+	ch = tr.Changed()
+	tr.Touch("s1", "/a/")
+	select {
+	case <-ch:
+		t.Fatal("Changed was closed for an unchanged heartbeat")
+	default:
+	}
+	tr.Touch("s1", "/b/")
+	select {
+	case <-ch:
+	default:
+		t.Fatal("Changed was not closed after the page changed")
+	}
+}
@@ -0,0 +1,107 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/webbleen/go-gin/pkg/iputil"
+)
+
+// IP 存储模式
+const (
+	ModeFull     = "full"     // 保存完整地址
+	ModeTruncate = "truncate" // IPv4 截断到 /24，IPv6 截断到 /48
+	ModeHash     = "hash"     // 按天轮换盐值的 HMAC，只能用于同一天内去重
+	ModeDrop     = "drop"     // 不保存
+)
+
+// HashPrefix 哈希值前缀，便于与真实地址区分
+const HashPrefix = "h:"
+
+var (
+	mode     = ModeFull
+	key      []byte
+	saltDate string
+	salt     []byte
+	mu       sync.Mutex
+)
+
+// Configure 设置存储模式和 HMAC 密钥，hash 模式下密钥为空时随机生成
+// 随机密钥在重启后会变化，同一天内重启前后的访客无法去重
+func Configure(m string, hashKey string) (generated bool, err error) {
+	switch m {
+	case ModeFull, ModeTruncate, ModeHash, ModeDrop:
+	default:
+		return false, fmt.Errorf("unknown IP privacy mode: %q", m)
+	}
+
+	k := []byte(hashKey)
+	if m == ModeHash && len(k) == 0 {
+		k = make([]byte, 32)
+		if _, err := rand.Read(k); err != nil {
+			return false, err
+		}
+		generated = true
+	}
+
+	mu.Lock()
+	mode, key = m, k
+	saltDate, salt = "", nil
+	mu.Unlock()
+	return generated, nil
+}
+
+// Mode 当前存储模式
+func Mode() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return mode
+}
+
+// AnonymizeIP 按当前模式处理待存储的 IP，t 为访问时间，按 t 所在时区的日期决定当天的盐值
+func AnonymizeIP(ip string, t time.Time) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch mode {
+	case ModeDrop:
+		return ""
+	case ModeTruncate:
+		addr, ok := iputil.Parse(ip)
+		if !ok {
+			return ""
+		}
+		bits := 24
+		if addr.Is6() {
+			bits = 48
+		}
+		prefix, _ := addr.Prefix(bits)
+		return prefix.Addr().String()
+	case ModeHash:
+		addr, ok := iputil.Parse(ip)
+		if !ok {
+			return ""
+		}
+		return HashPrefix + hashAddr(addr, t.Format("2006-01-02"))
+	default:
+		return ip
+	}
+}
+
+// hashAddr 使用当天的盐值计算地址的 HMAC，调用方需持有 mu
+func hashAddr(addr netip.Addr, date string) string {
+	if saltDate != date {
+		m := hmac.New(sha256.New, key)
+		m.Write([]byte(date))
+		salt, saltDate = m.Sum(nil), date
+	}
+	m := hmac.New(sha256.New, salt)
+	m.Write([]byte(addr.String()))
+	return hex.EncodeToString(m.Sum(nil))[:32]
+}
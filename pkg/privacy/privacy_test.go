@@ -0,0 +1,94 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMode 切换存储模式，测试结束后恢复为 full
+func useMode(t *testing.T, m, hashKey string) {
+	t.Helper()
+	if _, err := Configure(m, hashKey); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Configure(ModeFull, "") })
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	day := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		mode string
+		ip   string
+		want string
+	}{
+		{ModeFull, "203.0.113.77", "203.0.113.77"},
+		{ModeFull, "", ""},
+		{ModeTruncate, "203.0.113.77", "203.0.113.0"},
+		{ModeTruncate, "::ffff:203.0.113.77", "203.0.113.0"},
+		{ModeTruncate, "2001:db8:abcd:1234::1", "2001:db8:abcd::"},
+		{ModeTruncate, "unknown", ""},
+		{ModeDrop, "203.0.113.77", ""},
+		{ModeHash, "garbage", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.ip, func(t *testing.T) {
+			useMode(t, tt.mode, "test-key")
+			if got := AnonymizeIP(tt.ip, day); got != tt.want {
+				t.Errorf("AnonymizeIP(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizeIPHash(t *testing.T) {
+	useMode(t, ModeHash, "test-key")
+	morning := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 3, 4, 22, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	a := AnonymizeIP("203.0.113.77", morning)
+	if !strings.HasPrefix(a, HashPrefix) || len(a) != len(HashPrefix)+32 || strings.Contains(a, "203.0.113") {
+		t.Fatalf("hash = %q", a)
+	}
+	// 同一天内稳定，IPv4 映射地址与原地址相同
+	if b := AnonymizeIP("::ffff:203.0.113.77", evening); b != a {
+		t.Errorf("same-day hash = %q, want %q", b, a)
+	}
+	if b := AnonymizeIP("203.0.113.78", morning); b == a {
+		t.Error("different addresses produced the same hash")
+	}
+	// 盐值按天轮换
+	if b := AnonymizeIP("203.0.113.77", nextDay); b == a {
+		t.Error("hash did not change on the next day")
+	}
+	// 回到前一天时重新计算出相同的盐值
+	if b := AnonymizeIP("203.0.113.77", morning); b != a {
+		t.Errorf("hash for the first day changed to %q", b)
+	}
+
+	// 不同的密钥得到不同的哈希
+	useMode(t, ModeHash, "other-key")
+	if b := AnonymizeIP("203.0.113.77", morning); b == a {
+		t.Error("hash does not depend on the key")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { Configure(ModeFull, "") })
+
+	if _, err := Configure("maybe", ""); err == nil {
+		t.Error("Configure accepted an unknown mode")
+	}
+	generated, err := Configure(ModeHash, "")
+	if err != nil || !generated {
+		t.Errorf("Configure(hash, \"\") = %v, %v, want a generated key", generated, err)
+	}
+	generated, err = Configure(ModeHash, "fixed")
+	if err != nil || generated {
+		t.Errorf("Configure(hash, key) = %v, %v, want no generated key", generated, err)
+	}
+	if Mode() != ModeHash {
+		t.Errorf("Mode() = %q, want %q", Mode(), ModeHash)
+	}
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i, want := range []bool{true, true, false, false} {
+		if got := l.Allow("a"); got != want {
+			t.Errorf("request %d for a = %v, want %v", i+1, got, want)
+		}
+	}
+	// 不同 key 分别计数
+	if !l.Allow("b") {
+		t.Error("b was limited by requests for a")
+	}
+
+	// 窗口结束后重新计数，过期的 key 被清理
+	now = now.Add(time.Minute)
+	if !l.Allow("a") {
+		t.Error("a was still limited in the next window")
+	}
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("expired key b was not swept")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		if !l.Allow("a") {
+			t.Fatal("disabled limiter rejected a request")
+		}
+	}
+	var nilLimiter *Limiter
+	if !nilLimiter.Allow("a") {
+		t.Error("nil limiter rejected a request")
+	}
+}
@@ -0,0 +1,78 @@
+package referrer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Campaign 页面 URL 中的 UTM 参数
+type Campaign struct {
+	Source   string
+	Medium   string
+	Campaign string
+}
+
+// ParseCampaign 提取页面 URL 中的 utm_source / utm_medium / utm_campaign，
+// 并返回去掉全部 utm_* 参数后的 URL，避免同一页面因推广参数不同被拆成多个页面统计
+func ParseCampaign(page string) (Campaign, string) {
+	i := strings.IndexByte(page, '?')
+	if i < 0 {
+		return Campaign{}, page
+	}
+	path, rawQuery := page[:i], page[i+1:]
+	fragment := ""
+	if j := strings.IndexByte(rawQuery, '#'); j >= 0 {
+		rawQuery, fragment = rawQuery[:j], rawQuery[j:]
+	}
+
+	var c Campaign
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, "utm_") {
+			kept = append(kept, pair)
+			continue
+		}
+		v, err := url.QueryUnescape(value)
+		if err != nil {
+			v = value
+		}
+		v = strings.TrimSpace(v)
+		switch name {
+		case "utm_source":
+			c.Source = v
+		case "utm_medium":
+			c.Medium = v
+		case "utm_campaign":
+			c.Campaign = v
+		}
+	}
+
+	if len(kept) > 0 {
+		path += "?" + strings.Join(kept, "&")
+	}
+	return c, path + fragment
+}
+
+// MediumChannel 根据 utm_medium 推断渠道，无法推断时返回空字符串
+func MediumChannel(medium string) string {
+	switch strings.ToLower(strings.TrimSpace(medium)) {
+	case "email", "e-mail", "newsletter":
+		return ChannelEmail
+	case "social", "social-media", "sm":
+		return ChannelSocial
+	case "cpc", "ppc", "paidsearch", "organic":
+		return ChannelSearch
+	case "referral":
+		return ChannelReferral
+	}
+	return ""
+}
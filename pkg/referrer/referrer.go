@@ -0,0 +1,186 @@
+package referrer
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 来源渠道
+const (
+	ChannelDirect   = "direct"   // 没有来源（直接输入、书签、App 内打开等）
+	ChannelInternal = "internal" // 站内跳转
+	ChannelSearch   = "search"
+	ChannelSocial   = "social"
+	ChannelEmail    = "email"
+	ChannelReferral = "referral" // 其他外部网站
+)
+
+//go:embed sources.txt
+var embeddedSources []byte
+
+// Result 来源解析结果
+type Result struct {
+	Domain  string // 来源域名，去掉 www.；direct 时为空
+	Channel string
+	Term    string // 来源 URL 中的搜索关键词（已规范化），未知时为空
+}
+
+type source struct {
+	channel string
+	domain  string
+	any     bool     // 域名以 .* 结尾，匹配任意后缀
+	params  []string // 搜索词所在的查询参数
+}
+
+var (
+	sources   []source
+	siteHosts []string
+	mu        sync.RWMutex
+)
+
+func init() {
+	parsed, err := parseSources(embeddedSources)
+	if err != nil {
+		panic(fmt.Sprintf("referrer: invalid embedded sources: %v", err))
+	}
+	sources = parsed
+}
+
+// LoadSourcesFile 使用外部文件替换来源域名列表
+func LoadSourcesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseSources(data)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	sources = parsed
+	mu.Unlock()
+	return nil
+}
+
+// SetSiteHosts 设置本站域名，来源属于这些域名（含子域名）时视为站内跳转
+func SetSiteHosts(hosts []string) {
+	normalized := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		if h = normalizeHost(h); h != "" {
+			normalized = append(normalized, h)
+		}
+	}
+	mu.Lock()
+	siteHosts = normalized
+	mu.Unlock()
+}
+
+// Classify 解析来源 URL，extraHosts 为额外视为本站的域名（如发起统计请求的页面域名）
+func Classify(ref string, extraHosts ...string) Result {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Result{Channel: ChannelDirect}
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Hostname() == "" {
+		return Result{Channel: ChannelDirect}
+	}
+	host := normalizeHost(u.Hostname())
+	res := Result{Domain: host, Channel: ChannelReferral}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, h := range extraHosts {
+		if h = normalizeHost(h); h != "" && matchDomain(host, h) {
+			res.Channel = ChannelInternal
+			return res
+		}
+	}
+	for _, h := range siteHosts {
+		if matchDomain(host, h) {
+			res.Channel = ChannelInternal
+			return res
+		}
+	}
+	for _, s := range sources {
+		if s.match(host) {
+			res.Channel = s.channel
+			res.Term = s.term(u.Query())
+			return res
+		}
+	}
+	return res
+}
+
+// Host 取 URL 中去掉 www. 的域名，无法解析时返回空字符串
+func Host(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
+
+func (s source) match(host string) bool {
+	if !s.any {
+		return matchDomain(host, s.domain)
+	}
+	return strings.HasPrefix(host, s.domain+".") || strings.Contains(host, "."+s.domain+".")
+}
+
+// term 按顺序取第一个非空的搜索词参数
+func (s source) term(query url.Values) string {
+	for _, p := range s.params {
+		if t := NormalizeTerm(query.Get(p)); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// matchDomain host 是否为 domain 或其子域名
+func matchDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	return strings.TrimPrefix(host, "www.")
+}
+
+func parseSources(data []byte) ([]source, error) {
+	var result []source
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		switch fields[0] {
+		case ChannelSearch, ChannelSocial, ChannelEmail, ChannelReferral:
+		default:
+			return nil, fmt.Errorf("unknown channel: %q", fields[0])
+		}
+		domain := normalizeHost(fields[1])
+		s := source{channel: fields[0], domain: domain}
+		if len(fields) == 3 {
+			s.params = splitParams(fields[2])
+		}
+		if strings.HasSuffix(domain, ".*") {
+			s.domain, s.any = strings.TrimSuffix(domain, ".*"), true
+		}
+		result = append(result, s)
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package referrer
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	SetSiteHosts([]string{"Example.com"})
+	t.Cleanup(func() { SetSiteHosts(nil) })
+
+	tests := []struct {
+		name  string
+		ref   string
+		extra []string
+		want  Result
+	}{
+		{"empty", "", nil, Result{Channel: ChannelDirect}},
+		{"no host", "not a url", nil, Result{Channel: ChannelDirect}},
+		{"site host subdomain", "https://blog.example.com/post", nil, Result{Domain: "blog.example.com", Channel: ChannelInternal}},
+		{"request origin host", "https://www.mysite.dev/a", []string{"mysite.dev"}, Result{Domain: "mysite.dev", Channel: ChannelInternal}},
+		{"google country domain with term", "https://www.google.co.uk/search?q=Hugo++Themes", nil, Result{Domain: "google.co.uk", Channel: ChannelSearch, Term: "hugo themes"}},
+		{"baidu second param", "https://www.baidu.com/s?word=Go", nil, Result{Domain: "baidu.com", Channel: ChannelSearch, Term: "go"}},
+		{"search without term", "https://www.bing.com/", nil, Result{Domain: "bing.com", Channel: ChannelSearch}},
+		{"webmail before search", "https://mail.google.com/mail/u/0/", nil, Result{Domain: "mail.google.com", Channel: ChannelEmail}},
+		{"unknown site", "https://someblog.net/post", nil, Result{Domain: "someblog.net", Channel: ChannelReferral}},
+		{"lookalike is not google", "https://notgoogle.com/?q=x", nil, Result{Domain: "notgoogle.com", Channel: ChannelReferral}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.ref, tt.extra...); got != tt.want {
+				t.Errorf("Classify(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	if _, err := parseSources([]byte("search example.com q\nunknown other.com\n")); err == nil {
+		t.Error("parseSources accepted an unknown channel")
+	}
+	if _, err := parseSources([]byte("search\n")); err == nil {
+		t.Error("parseSources accepted a line without a domain")
+	}
+	parsed, err := parseSources([]byte("# comment\n\nsearch Search.Example.* k,q\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := parsed[0]; s.domain != "search.example" || !s.any || len(s.params) != 2 {
+		t.Errorf("parseSources = %+v", s)
+	}
+}
+
+func TestParseCampaign(t *testing.T) {
+	tests := []struct {
+		page     string
+		want     Campaign
+		wantPage string
+	}{
+		{"/posts/a/", Campaign{}, "/posts/a/"},
+		{"/posts/a/?utm_source=Newsletter&utm_medium=email&utm_campaign=spring%20sale", Campaign{"Newsletter", "email", "spring sale"}, "/posts/a/"},
+		{"/posts/a/?page=2&UTM_SOURCE=x&utm_term=y#top", Campaign{Source: "x"}, "/posts/a/?page=2#top"},
+	}
+	for _, tt := range tests {
+		got, page := ParseCampaign(tt.page)
+		if got != tt.want || page != tt.wantPage {
+			t.Errorf("ParseCampaign(%q) = %+v, %q, want %+v, %q", tt.page, got, page, tt.want, tt.wantPage)
+		}
+	}
+}
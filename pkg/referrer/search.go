@@ -0,0 +1,65 @@
+package referrer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MaxTermLength 搜索关键词保存的最大字符数
+const MaxTermLength = 100
+
+var siteSearchParams []string
+
+// SetSiteSearchParams 设置站内搜索页面 URL 中关键词所在的查询参数，为空时不提取站内搜索
+func SetSiteSearchParams(params []string) {
+	normalized := make([]string, 0, len(params))
+	for _, p := range params {
+		if p = strings.TrimSpace(p); p != "" {
+			normalized = append(normalized, p)
+		}
+	}
+	mu.Lock()
+	siteSearchParams = normalized
+	mu.Unlock()
+}
+
+// SiteSearchTerm 提取页面 URL 中的站内搜索关键词（已规范化），没有时返回空字符串
+func SiteSearchTerm(page string) string {
+	mu.RLock()
+	params := siteSearchParams
+	mu.RUnlock()
+	if len(params) == 0 || !strings.Contains(page, "?") {
+		return ""
+	}
+	u, err := url.Parse(page)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	for _, p := range params {
+		if t := NormalizeTerm(query.Get(p)); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// NormalizeTerm 规范化搜索关键词：转小写、合并连续空白，截断到 MaxTermLength 个字符
+func NormalizeTerm(term string) string {
+	term = strings.Join(strings.Fields(strings.ToLower(term)), " ")
+	if r := []rune(term); len(r) > MaxTermLength {
+		term = strings.TrimSpace(string(r[:MaxTermLength]))
+	}
+	return term
+}
+
+// splitParams 拆分逗号分隔的参数名
+func splitParams(s string) []string {
+	var params []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
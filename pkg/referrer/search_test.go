@@ -0,0 +1,45 @@
+package referrer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSiteSearchTerm(t *testing.T) {
+	tests := []struct {
+		params []string
+		page   string
+		want   string
+	}{
+		{nil, "/search/?q=hugo", ""},
+		{[]string{"q"}, "/search/?q=Hugo%20Themes", "hugo themes"},
+		{[]string{"s", "q"}, "/search/?s=&q=go", "go"},
+		{[]string{"q"}, "/posts/hello/", ""},
+		{[]string{"q"}, "/search/?q=+++", ""},
+	}
+	t.Cleanup(func() { SetSiteSearchParams(nil) })
+	for _, tt := range tests {
+		SetSiteSearchParams(tt.params)
+		if got := SiteSearchTerm(tt.page); got != tt.want {
+			t.Errorf("SiteSearchTerm(%q) with %v = %q, want %q", tt.page, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTerm(t *testing.T) {
+	long := strings.Repeat("搜", MaxTermLength+5)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"  Hello \t World ", "hello world"},
+		{"", ""},
+		{long, strings.Repeat("搜", MaxTermLength)},
+		{strings.Repeat("a", MaxTermLength-1) + " b", strings.Repeat("a", MaxTermLength-1)},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTerm(tt.in); got != tt.want {
+			t.Errorf("NormalizeTerm(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
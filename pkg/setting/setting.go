@@ -18,15 +18,75 @@ var (
 	// 应用配置
 	PageSize  int
 	JwtSecret string
+	JwtExpire time.Duration
+
+	// 管理员认证配置（用于签发访问令牌）
+	AdminUsername string
+	AdminPassword string
 
 	// 数据库配置
 	DatabaseURL string
 
+	// 客户端 IP 解析配置
+	TrustedProxies  []string
+	ProxyPlatforms  []string
+	ClientIPHeaders []string
+	PrivateIPMode   string
+	EgressIP        string
+	EgressIPURLs    []string
+	EgressIPRefresh time.Duration
+
 	// CORS 配置
 	CORSAllowedOrigins []string
 	CORSAllowedMethods []string
 	CORSAllowedHeaders []string
 	CORSCredentials    bool
+
+	// 访问统计配置
+	UAParseMode string
+	UARulesFile string
+	// 已知爬虫 IP 段文件
+	BotIPRangesFile string
+	// 浏览器请求缺少 Accept-Language 时是否单独判定为机器人
+	BotRequireAcceptLanguage bool
+	// 本站域名与来源域名列表文件
+	SiteHosts           []string
+	ReferrerSourcesFile string
+	SiteSearchParams    []string
+	// 报表时区
+	ReportTimeZone string
+	// 访问记录 IP 存储模式
+	IPPrivacyMode string
+	IPHashKey     string
+	// 原始访问记录保留与汇总
+	RetentionDays     int
+	RetentionAction   string
+	RetentionInterval time.Duration
+	// 访问记录异步写入
+	IngestAsync         bool
+	IngestQueueSize     int
+	IngestBatchSize     int
+	IngestFlushInterval time.Duration
+	IngestOverflow      string
+	IngestSpoolDir      string
+	IngestSpoolMaxBytes int64
+	IngestDedupeSize    int
+
+	// GeoIP 配置
+	GeoIPDBPath         string
+	GeoIPLocale         string
+	GeoIPReloadInterval time.Duration
+	GeoIPHTTPFallback   bool
+
+	// 聊天配置
+	ChatProvider     string
+	ChatAPIURL       string
+	ChatAPIKey       string
+	ChatModel        string
+	ChatTimeout      time.Duration
+	ChatHistoryLimit int
+	ChatMaxLength    int
+	ChatRateLimit    int
 )
 
 func init() {
@@ -38,7 +98,11 @@ func LoadAll() {
 	LoadServer()
 	LoadApp()
 	LoadDatabase()
+	LoadProxy()
 	LoadCORS()
+	LoadStats()
+	LoadGeoIP()
+	LoadChat()
 }
 
 // LoadServer 加载服务器配置
@@ -54,10 +118,36 @@ func LoadServer() {
 	WriteTimeout = time.Duration(getEnvInt("WRITE_TIMEOUT", 60)) * time.Second
 }
 
+// insecureJwtSecrets 旧版本的默认密钥和 env.example 中的示例值，任何人都能用它们签发令牌
+var insecureJwtSecrets = []string{"!@)*#)!@U#@*!@!)", "your_jwt_secret_here"}
+
+// JwtSecretConfigured JWT 密钥是否已配置为非公开的值
+func JwtSecretConfigured() bool {
+	if JwtSecret == "" {
+		return false
+	}
+	for _, s := range insecureJwtSecrets {
+		if JwtSecret == s {
+			return false
+		}
+	}
+	return true
+}
+
 // LoadApp 加载应用配置
 func LoadApp() {
-	// JWT 密钥
-	JwtSecret = getEnv("JWT_SECRET", "!@)*#)!@U#@*!@!)")
+	// JWT 密钥，未配置或仍为公开的示例值时不签发也不接受令牌
+	JwtSecret = getEnv("JWT_SECRET", "")
+	if !JwtSecretConfigured() {
+		log.Printf("JWT_SECRET 未配置或为示例值，管理接口的令牌认证已停用")
+	}
+
+	// 令牌有效期（小时）
+	JwtExpire = time.Duration(getEnvInt("JWT_EXPIRE_HOURS", 24)) * time.Hour
+
+	// 管理员账号，未配置密码时不签发令牌
+	AdminUsername = getEnv("ADMIN_USERNAME", "admin")
+	AdminPassword = getEnv("ADMIN_PASSWORD", "")
 
 	// 分页大小
 	PageSize = getEnvInt("PAGE_SIZE", 10)
@@ -69,6 +159,49 @@ func LoadDatabase() {
 	DatabaseURL = getEnv("DATABASE_URL", "")
 }
 
+// LoadProxy 加载客户端 IP 解析配置
+func LoadProxy() {
+	// 可信代理地址段，只有直连对端属于这些地址段时才读取客户端 IP 请求头
+	// 默认信任回环和私有网段（Railway 等平台的内部负载均衡）
+	TrustedProxies = splitAndTrim(getEnv("TRUSTED_PROXIES",
+		"127.0.0.0/8,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,100.64.0.0/10,::1/128,fc00::/7"))
+
+	// 服务前面的平台（netlify、cloudflare），只有配置后才读取该平台的客户端 IP 请求头
+	ProxyPlatforms = splitAndTrim(strings.ToLower(getEnv("PROXY_PLATFORM", "")))
+	// 按优先级读取的客户端 IP 请求头，默认为已配置平台的请求头加 X-Forwarded-For
+	// 平台请求头会被原样采信，未经过该平台时客户端可以任意伪造
+	ClientIPHeaders = splitAndTrim(getEnv("CLIENT_IP_HEADERS", ""))
+	if len(ClientIPHeaders) == 0 {
+		ClientIPHeaders = platformIPHeaders(ProxyPlatforms)
+	}
+
+	// 客户端 IP 为私有地址时的处理：private（如实返回）或 egress（返回服务器出口 IP 缓存）
+	PrivateIPMode = getEnv("PRIVATE_IP_MODE", "private")
+	// 静态出口 IP，配置后不再请求外部服务
+	EgressIP = getEnv("EGRESS_IP", "")
+	// 获取出口 IP 的服务，仅在 egress 模式且未配置 EGRESS_IP 时于后台定时请求
+	EgressIPURLs = splitAndTrim(getEnv("EGRESS_IP_URLS",
+		"https://api.ipify.org?format=text,https://ipv4.icanhazip.com,https://checkip.amazonaws.com"))
+	// 出口 IP 刷新间隔（秒）
+	EgressIPRefresh = time.Duration(getEnvInt("EGRESS_IP_REFRESH", 600)) * time.Second
+}
+
+// platformIPHeaders 按平台生成默认的客户端 IP 请求头，X-Forwarded-For 始终放在最后
+func platformIPHeaders(platforms []string) []string {
+	var headers []string
+	for _, p := range platforms {
+		switch p {
+		case "netlify":
+			headers = append(headers, "X-Nf-Client-Connection-Ip")
+		case "cloudflare":
+			headers = append(headers, "CF-Connecting-IP")
+		default:
+			log.Printf("Unknown PROXY_PLATFORM %q, ignored", p)
+		}
+	}
+	return append(headers, "X-Forwarded-For")
+}
+
 // LoadCORS 加载 CORS 配置
 func LoadCORS() {
 	// 允许的来源
@@ -87,6 +220,82 @@ func LoadCORS() {
 	CORSCredentials = getEnvBool("CORS_CREDENTIALS", false)
 }
 
+// LoadStats 加载访问统计配置
+func LoadStats() {
+	// User-Agent 解析模式：fill（仅补全前端未传的字段）、always（始终以服务端解析为准）、off（关闭）
+	UAParseMode = getEnv("UA_PARSE_MODE", "fill")
+	// 自定义 User-Agent 规则文件（JSON），为空时使用内置规则
+	UARulesFile = getEnv("UA_RULES_FILE", "")
+	// 自定义爬虫 IP 段文件（每行 "<名称> <CIDR>"），为空时使用内置列表
+	BotIPRangesFile = getEnv("BOT_IP_RANGES_FILE", "")
+	BotRequireAcceptLanguage = getEnvBool("BOT_REQUIRE_ACCEPT_LANGUAGE", false)
+	// 本站域名（逗号分隔，含子域名），来源属于这些域名时视为站内跳转
+	SiteHosts = splitAndTrim(getEnv("SITE_HOSTS", ""))
+	// 自定义来源域名文件（每行 "<渠道> <域名>"），为空时使用内置列表
+	ReferrerSourcesFile = getEnv("REFERRER_SOURCES_FILE", "")
+	// 站内搜索关键词所在的查询参数（逗号分隔），为空时不提取站内搜索
+	SiteSearchParams = splitAndTrim(getEnv("SITE_SEARCH_PARAMS", ""))
+	// 报表时区（IANA 名称，如 Asia/Shanghai），决定“今天”、按天去重和按天统计的日期划分，为空时使用服务器本地时区
+	ReportTimeZone = getEnv("REPORT_TIMEZONE", "")
+	// 访问记录 IP 存储模式：full（完整）、truncate（IPv4 /24、IPv6 /48）、hash（按天轮换盐值的 HMAC）、drop（不保存）
+	IPPrivacyMode = getEnv("IP_PRIVACY_MODE", "full")
+	// hash 模式的 HMAC 密钥，为空时启动时随机生成
+	IPHashKey = getEnv("IP_HASH_KEY", "")
+
+	// 原始访问记录保留天数（含今天），超期记录汇总到 daily_stats / visit_rollup；0 表示永久保留
+	RetentionDays = getEnvInt("RETENTION_DAYS", 0)
+	// 汇总后原始记录的处理：archive（移入 visit_record_archive）或 delete（删除）
+	RetentionAction = getEnv("RETENTION_ACTION", "archive")
+	// 汇总任务执行间隔（分钟）
+	RetentionInterval = time.Duration(getEnvInt("RETENTION_INTERVAL", 60)) * time.Minute
+
+	// 是否通过进程内队列批量写入访问记录，关闭时每次请求同步写库
+	IngestAsync = getEnvBool("INGEST_ASYNC", true)
+	// 队列容量，超出后按 INGEST_OVERFLOW 处理
+	IngestQueueSize = getEnvInt("INGEST_QUEUE_SIZE", 10000)
+	// 单次批量写入的最大条数
+	IngestBatchSize = getEnvInt("INGEST_BATCH_SIZE", 100)
+	// 不足一批时的最长等待时间（毫秒）
+	IngestFlushInterval = time.Duration(getEnvInt("INGEST_FLUSH_INTERVAL", 1000)) * time.Millisecond
+	// 队列满或数据库不可用时的处理：drop（丢弃）或 spool（写入本地文件，恢复后回放）
+	IngestOverflow = getEnv("INGEST_OVERFLOW", "drop")
+	// spool 文件目录，为空时使用系统临时目录
+	IngestSpoolDir = getEnv("INGEST_SPOOL_DIR", "")
+	// spool 文件大小上限（MB）
+	IngestSpoolMaxBytes = int64(getEnvInt("INGEST_SPOOL_MAX_MB", 64)) << 20
+	// 当日访问去重的内存集合容量，超出后回退到数据库查询
+	IngestDedupeSize = getEnvInt("INGEST_DEDUPE_SIZE", 100000)
+}
+
+// LoadGeoIP 加载 GeoIP 配置
+func LoadGeoIP() {
+	// MaxMind 格式（GeoLite2-City / GeoIP2-City）的本地数据库文件
+	GeoIPDBPath = getEnv("GEOIP_DB_PATH", "")
+	// 返回的地名语言，数据库中没有该语言时回退到 en
+	GeoIPLocale = getEnv("GEOIP_LOCALE", "en")
+	// 检查数据库文件更新的间隔（秒），0 表示不自动重新加载
+	GeoIPReloadInterval = time.Duration(getEnvInt("GEOIP_RELOAD_INTERVAL", 300)) * time.Second
+	// 本地数据库未命中时是否回退到第三方 HTTP 接口（ip-api.com、ipinfo.io、ipapi.co）
+	GeoIPHTTPFallback = getEnvBool("GEOIP_HTTP_FALLBACK", false)
+}
+
+// LoadChat 加载聊天配置
+func LoadChat() {
+	// 回复生成器：echo（本地回显）或 openai（兼容 OpenAI Chat Completions 的接口）
+	ChatProvider = getEnv("CHAT_PROVIDER", "echo")
+	ChatAPIURL = getEnv("CHAT_API_URL", "https://api.openai.com/v1")
+	ChatAPIKey = getEnv("CHAT_API_KEY", "")
+	ChatModel = getEnv("CHAT_MODEL", "gpt-4o-mini")
+	ChatTimeout = time.Duration(getEnvInt("CHAT_TIMEOUT", 60)) * time.Second
+
+	// 生成回复时携带的历史消息条数
+	ChatHistoryLimit = getEnvInt("CHAT_HISTORY_LIMIT", 20)
+	// 单条消息的最大字符数
+	ChatMaxLength = getEnvInt("CHAT_MAX_LENGTH", 2000)
+	// 同一客户端 IP、同一会话每分钟最多发送的消息数，0 表示不限制
+	ChatRateLimit = getEnvInt("CHAT_RATE_LIMIT", 10)
+}
+
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -140,11 +349,24 @@ func PrintConfig() {
 	log.Printf("读取超时: %v", ReadTimeout)
 	log.Printf("写入超时: %v", WriteTimeout)
 	log.Printf("分页大小: %d", PageSize)
+	log.Printf("令牌有效期: %v", JwtExpire)
+	log.Printf("管理员账号: %s (密码已配置: %t)", AdminUsername, AdminPassword != "")
 	log.Printf("数据库 URL: %s", maskSensitiveInfo(DatabaseURL))
+	log.Printf("可信代理: %v", TrustedProxies)
+	log.Printf("代理平台: %v", ProxyPlatforms)
+	log.Printf("客户端 IP 请求头: %v", ClientIPHeaders)
+	log.Printf("私有 IP 处理模式: %s", PrivateIPMode)
 	log.Printf("CORS 允许来源: %v", CORSAllowedOrigins)
 	log.Printf("CORS 允许方法: %v", CORSAllowedMethods)
 	log.Printf("CORS 允许头部: %v", CORSAllowedHeaders)
 	log.Printf("CORS 允许凭据: %t", CORSCredentials)
+	log.Printf("User-Agent 解析模式: %s", UAParseMode)
+	log.Printf("IP 存储模式: %s", IPPrivacyMode)
+	log.Printf("报表时区: %s", ReportTimeZone)
+	log.Printf("原始访问记录保留天数: %d (%s)", RetentionDays, RetentionAction)
+	log.Printf("访问记录异步写入: %t (队列: %d, 批量: %d, 溢出: %s)", IngestAsync, IngestQueueSize, IngestBatchSize, IngestOverflow)
+	log.Printf("GeoIP 数据库: %s (HTTP 回退: %t)", GeoIPDBPath, GeoIPHTTPFallback)
+	log.Printf("聊天回复生成器: %s (模型: %s)", ChatProvider, ChatModel)
 	log.Printf("================")
 }
 
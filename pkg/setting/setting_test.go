@@ -0,0 +1,24 @@
+package setting
+
+import "testing"
+
+func TestJwtSecretConfigured(t *testing.T) {
+	original := JwtSecret
+	t.Cleanup(func() { JwtSecret = original })
+
+	tests := []struct {
+		secret string
+		want   bool
+	}{
+		{"", false},
+		{"!@)*#)!@U#@*!@!)", false},
+		{"your_jwt_secret_here", false},
+		{"a-private-secret", true},
+	}
+	for _, tt := range tests {
+		JwtSecret = tt.secret
+		if got := JwtSecretConfigured(); got != tt.want {
+			t.Errorf("JwtSecretConfigured() with %q = %v, want %v", tt.secret, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package useragent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+//go:embed rules.json
+var embeddedRules []byte
+
+// Result User-Agent 解析结果
+type Result struct {
+	Device         string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	IsBot          bool
+	BotName        string
+}
+
+// Rule 规则文件中的一条规则，按顺序匹配，命中第一条即停止
+// pattern 的第一个非空捕获组作为版本号；exclude 命中时跳过该规则；
+// versions 用于把原始版本号映射为可读版本（如 Windows NT 10.0 → 10）
+type Rule struct {
+	Name     string            `json:"name"`
+	Pattern  string            `json:"pattern"`
+	Exclude  string            `json:"exclude,omitempty"`
+	Versions map[string]string `json:"versions,omitempty"`
+}
+
+// RuleSet 规则文件结构
+type RuleSet struct {
+	Bots     []Rule `json:"bots"`
+	Browsers []Rule `json:"browsers"`
+	OS       []Rule `json:"os"`
+	Devices  []Rule `json:"devices"`
+}
+
+type compiledRule struct {
+	Rule
+	re      *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// Parser User-Agent 解析器
+type Parser struct {
+	bots     []compiledRule
+	browsers []compiledRule
+	os       []compiledRule
+	devices  []compiledRule
+}
+
+var (
+	defaultParser *Parser
+	mu            sync.RWMutex
+)
+
+func init() {
+	parser, err := NewParser(embeddedRules)
+	if err != nil {
+		panic(fmt.Sprintf("useragent: invalid embedded rules: %v", err))
+	}
+	defaultParser = parser
+}
+
+// NewParser 从 JSON 规则创建解析器
+func NewParser(data []byte) (*Parser, error) {
+	var rules RuleSet
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	p := &Parser{}
+	var err error
+	if p.bots, err = compileRules(rules.Bots); err != nil {
+		return nil, err
+	}
+	if p.browsers, err = compileRules(rules.Browsers); err != nil {
+		return nil, err
+	}
+	if p.os, err = compileRules(rules.OS); err != nil {
+		return nil, err
+	}
+	if p.devices, err = compileRules(rules.Devices); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadRulesFile 使用外部规则文件替换默认解析器，无需修改代码即可更新规则
+func LoadRulesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	parser, err := NewParser(data)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defaultParser = parser
+	mu.Unlock()
+	return nil
+}
+
+// Parse 使用默认解析器解析 User-Agent
+func Parse(ua string) Result {
+	mu.RLock()
+	parser := defaultParser
+	mu.RUnlock()
+	return parser.Parse(ua)
+}
+
+// Parse 解析 User-Agent
+func (p *Parser) Parse(ua string) Result {
+	var res Result
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return res
+	}
+
+	if rule, _, ok := match(p.bots, ua); ok {
+		res.IsBot = true
+		res.BotName = rule.Name
+	}
+	if rule, version, ok := match(p.browsers, ua); ok {
+		res.Browser = rule.Name
+		res.BrowserVersion = version
+	}
+	if rule, version, ok := match(p.os, ua); ok {
+		res.OS = rule.Name
+		res.OSVersion = version
+	}
+
+	switch {
+	case res.IsBot:
+		res.Device = DeviceBot
+	default:
+		res.Device = DeviceDesktop
+		if rule, _, ok := match(p.devices, ua); ok {
+			res.Device = rule.Name
+		}
+	}
+	return res
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %v", r.Name, err)
+		}
+		cr := compiledRule{Rule: r, re: re}
+		if r.Exclude != "" {
+			if cr.exclude, err = regexp.Compile(r.Exclude); err != nil {
+				return nil, fmt.Errorf("rule %q: %v", r.Name, err)
+			}
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled, nil
+}
+
+// match 返回第一条命中的规则和解析出的版本号
+func match(rules []compiledRule, ua string) (compiledRule, string, bool) {
+	for _, r := range rules {
+		m := r.re.FindStringSubmatch(ua)
+		if m == nil {
+			continue
+		}
+		if r.exclude != nil && r.exclude.MatchString(ua) {
+			continue
+		}
+
+		version := ""
+		for _, group := range m[1:] {
+			if group != "" {
+				version = strings.ReplaceAll(group, "_", ".")
+				break
+			}
+		}
+		if mapped, ok := r.Versions[version]; ok {
+			version = mapped
+		}
+		return r, version, true
+	}
+	return compiledRule{}, "", false
+}
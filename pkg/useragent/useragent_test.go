@@ -0,0 +1,137 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Result
+	}{
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: Result{Device: DeviceDesktop, Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "Windows", OSVersion: "10"},
+		},
+		{
+			name: "edge is not chrome",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: Result{Device: DeviceDesktop, Browser: "Edge", BrowserVersion: "120.0.2210.91", OS: "Windows", OSVersion: "10"},
+		},
+		{
+			name: "safari on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: Result{Device: DeviceDesktop, Browser: "Safari", BrowserVersion: "17.1", OS: "macOS", OSVersion: "10.15.7"},
+		},
+		{
+			name: "iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			want: Result{Device: DeviceMobile, Browser: "Safari", BrowserVersion: "17.1", OS: "iOS", OSVersion: "17.1"},
+		},
+		{
+			name: "ipad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			want: Result{Device: DeviceTablet, Browser: "Safari", BrowserVersion: "16.6", OS: "iOS", OSVersion: "16.6"},
+		},
+		{
+			name: "android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: Result{Device: DeviceMobile, Browser: "Chrome", BrowserVersion: "120.0.6099.144", OS: "Android", OSVersion: "14"},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: Result{Device: DeviceDesktop, Browser: "Firefox", BrowserVersion: "121.0", OS: "Linux"},
+		},
+		{
+			name: "googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Result{Device: DeviceBot, IsBot: true, BotName: "Googlebot"},
+		},
+		{
+			name: "googlebot smartphone keeps browser fields",
+			ua:   "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.216 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: Result{Device: DeviceBot, Browser: "Chrome", BrowserVersion: "120.0.6099.216", OS: "Android", OSVersion: "6.0.1", IsBot: true, BotName: "Googlebot"},
+		},
+		{
+			name: "http client",
+			ua:   "curl/8.4.0",
+			want: Result{Device: DeviceBot, IsBot: true, BotName: "HTTP Client"},
+		},
+		{
+			name: "empty",
+			ua:   "  ",
+			want: Result{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.ua); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenericBot(t *testing.T) {
+	tests := []struct {
+		ua    string
+		isBot bool
+	}{
+		{"Mozilla/5.0 (compatible; SeznamBot/4.0; +https://o-seznam.cz/napoveda/vyhledavani/en/seznambot-crawler/)", true},
+		{"ExampleCrawler/1.2 (+https://crawler.example.com)", true},
+		{"Mozilla/5.0 (compatible; Qwantify/2.4w; +https://www.qwant.com/)/2.4w crawler", true},
+		{"my-site-scraper 0.3", true},
+		// 型号、应用名中包含 bot、preview、monitor 的真实设备
+		{"Mozilla/5.0 (Linux; Android 10; CUBOT X30 Build/QP1A.190711.020) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36", false},
+		{"Mozilla/5.0 (Linux; Android 11; CUBOT_NOTE_20) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36", false},
+		{"Mozilla/5.0 (Linux; Android 12; Robotics Tab 10) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", false},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15 QuickLookPreview/1.0", false},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 HeartMonitor/2.1", false},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.ua)
+		if got.IsBot != tt.isBot {
+			t.Errorf("Parse(%q).IsBot = %v, want %v", tt.ua, got.IsBot, tt.isBot)
+		}
+		if tt.isBot && got.BotName != "Generic Bot" {
+			t.Errorf("Parse(%q).BotName = %q, want Generic Bot", tt.ua, got.BotName)
+		}
+	}
+}
+
+func TestNewParserRules(t *testing.T) {
+	parser, err := NewParser([]byte(`{
+		"browsers": [
+			{"name": "Edge", "pattern": "Edg/([\\d.]+)"},
+			{"name": "Chrome", "pattern": "Chrome/([\\d.]+)", "exclude": "Edg/"}
+		],
+		"os": [
+			{"name": "Windows", "pattern": "Windows NT ([\\d.]+)", "versions": {"6.1": "7"}}
+		],
+		"devices": [
+			{"name": "mobile", "pattern": "Mobile"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ua   string
+		want Result
+	}{
+		{"Windows NT 6.1 Chrome/99.0", Result{Device: DeviceDesktop, Browser: "Chrome", BrowserVersion: "99.0", OS: "Windows", OSVersion: "7"}},
+		{"Windows NT 10.0 Chrome/99.0 Edg/99.1", Result{Device: DeviceDesktop, Browser: "Edge", BrowserVersion: "99.1", OS: "Windows", OSVersion: "10.0"}},
+		{"Mobile Chrome/1", Result{Device: DeviceMobile, Browser: "Chrome", BrowserVersion: "1"}},
+	}
+	for _, tt := range tests {
+		if got := parser.Parse(tt.ua); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.ua, got, tt.want)
+		}
+	}
+
+	if _, err := NewParser([]byte(`{"bots": [{"name": "bad", "pattern": "("}]}`)); err == nil {
+		t.Error("NewParser accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/webbleen/go-gin/pkg/setting"
+)
+
+// ErrJwtSecretNotConfigured JWT_SECRET 未配置或为公开的示例值
+var ErrJwtSecretNotConfigured = errors.New("JWT_SECRET is not configured")
+
+// Claims 访问令牌载荷
+type Claims struct {
+	Username string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken 为指定用户签发令牌，返回令牌和过期时间
+func GenerateToken(username string) (string, time.Time, error) {
+	if !setting.JwtSecretConfigured() {
+		return "", time.Time{}, ErrJwtSecretNotConfigured
+	}
+	nowTime := time.Now()
+	expireTime := nowTime.Add(setting.JwtExpire)
+
+	claims := Claims{
+		Username: username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "webbleen-api",
+			IssuedAt:  jwt.NewNumericDate(nowTime),
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+		},
+	}
+
+	tokenClaims := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token, err := tokenClaims.SignedString([]byte(setting.JwtSecret))
+	return token, expireTime, err
+}
+
+// ParseToken 校验并解析令牌，过期时返回的 error 满足 errors.Is(err, jwt.ErrTokenExpired)
+func ParseToken(token string) (*Claims, error) {
+	if !setting.JwtSecretConfigured() {
+		return nil, ErrJwtSecretNotConfigured
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(setting.JwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+	"gorm.io/gorm"
+)
+
+type apiKeyPayload struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Scopes      []string `json:"scopes"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// GetAPIKeys 获取 API Key 列表
+// @Summary 获取 API Key 列表
+// @Description 返回全部未删除的 API Key（不含明文密钥）
+// @Tags API Key
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /keys [get]
+func GetAPIKeys(c *gin.Context) {
+	keys, err := database.GetAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get api keys", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"keys": keys, "scopes": database.AllScopes}})
+}
+
+// GetAPIKey 获取单个 API Key
+// @Summary 获取 API Key
+// @Tags API Key
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /keys/{id} [get]
+func GetAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+	key, err := database.GetAPIKey(id)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": key})
+}
+
+// AddAPIKey 创建 API Key
+// @Summary 创建 API Key
+// @Description 创建新的 API Key，明文密钥只在本次响应中返回
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Param body body apiKeyPayload true "名称、描述和权限范围（stats:read / stats:write / export）"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /keys [post]
+func AddAPIKey(c *gin.Context) {
+	var payload apiKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Name == nil || *payload.Name == "" || len(payload.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "name and scopes are required", "data": gin.H{}})
+		return
+	}
+	description := ""
+	if payload.Description != nil {
+		description = *payload.Description
+	}
+
+	if _, err := database.NormalizeScopes(payload.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	key, plain, err := database.CreateAPIKey(*payload.Name, description, payload.Scopes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to create api key", "data": gin.H{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": e.SUCCESS,
+		"msg":  e.GetMsg(e.SUCCESS),
+		"data": gin.H{
+			"key":     plain,
+			"api_key": key,
+		},
+	})
+}
+
+// EditAPIKey 更新 API Key
+// @Summary 更新 API Key
+// @Description 修改名称、描述、权限范围或启用状态，未传递的字段保持不变
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Param body body apiKeyPayload true "需要修改的字段"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /keys/{id} [put]
+func EditAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+	var payload apiKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid JSON data", "data": gin.H{}})
+		return
+	}
+
+	data := make(map[string]interface{})
+	if payload.Name != nil {
+		data["name"] = *payload.Name
+	}
+	if payload.Description != nil {
+		data["description"] = *payload.Description
+	}
+	if payload.IsActive != nil {
+		data["is_active"] = *payload.IsActive
+	}
+	if payload.Scopes != nil {
+		scopes, err := database.NormalizeScopes(payload.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+			return
+		}
+		data["scopes"] = scopes
+	}
+
+	key, err := database.UpdateAPIKey(id, data)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": key})
+}
+
+// DeleteAPIKey 删除 API Key
+// @Summary 删除 API Key
+// @Description 软删除 API Key，删除后立即失效
+// @Tags API Key
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /keys/{id} [delete]
+func DeleteAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+	if err := database.DeleteAPIKey(id); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"deleted": true}})
+}
+
+func parseAPIKeyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": e.GetMsg(e.INVALID_PARAMS), "data": gin.H{}})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondAPIKeyError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": e.ERROR_NOT_EXIST_API_KEY, "msg": e.GetMsg(e.ERROR_NOT_EXIST_API_KEY), "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to access api key", "data": gin.H{}})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/util"
+)
+
+type authPayload struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// GetAuth 签发访问令牌
+// @Summary 获取访问令牌
+// @Description 使用管理员账号换取 JWT，用于访问 Dashboard、访问记录、导出和内容写入接口
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param body body authPayload true "管理员账号"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Failure 401 {object} map[string]interface{} "账号或密码错误"
+// @Router /auth [post]
+func GetAuth(c *gin.Context) {
+	var payload authPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": e.GetMsg(e.INVALID_PARAMS), "data": gin.H{}})
+		return
+	}
+
+	// 未配置 JWT 密钥时签发的令牌任何人都能伪造，直接拒绝
+	if !setting.JwtSecretConfigured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": e.ERROR_AUTH_TOKEN, "msg": "JWT_SECRET is not configured", "data": gin.H{}})
+		return
+	}
+	// 未配置管理员密码时拒绝签发，避免使用空密码登录
+	if setting.AdminPassword == "" || !checkAdmin(payload.Username, payload.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": e.ERROR_AUTH_LOGIN, "msg": e.GetMsg(e.ERROR_AUTH_LOGIN), "data": gin.H{}})
+		return
+	}
+
+	token, expiresAt, err := util.GenerateToken(payload.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR_AUTH_TOKEN, "msg": e.GetMsg(e.ERROR_AUTH_TOKEN), "data": gin.H{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": e.SUCCESS,
+		"msg":  e.GetMsg(e.SUCCESS),
+		"data": gin.H{
+			"token":      token,
+			"expires_at": expiresAt.Format("2006-01-02 15:04:05"),
+		},
+	})
+}
+
+// checkAdmin 常量时间比较账号密码
+func checkAdmin(username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(setting.AdminUsername)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(setting.AdminPassword)) == 1
+	return userOK && passOK
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/models/response"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// 批量上报的限制
+const (
+	batchMaxBytes = 64 << 10 // sendBeacon 在多数浏览器中限制为 64KB
+	batchMaxItems = 100
+)
+
+// 批量上报的数据类型
+const (
+	batchTypeVisit      = "visit"
+	batchTypeEvent      = "event"
+	batchTypeEngagement = "engagement"
+)
+
+// batchItem 批量上报中的单条数据，type 为空时视为访问记录，event、engagement 的字段分别与 /stats/event、/stats/engagement 相同
+type batchItem struct {
+	Type string `json:"type"`
+}
+
+// RecordBatch 批量记录访问
+// @Summary 批量记录访问
+// @Description 一次上报多条访问记录和事件（type 为 visit、event 或 engagement，默认 visit），供页面卸载时 navigator.sendBeacon 使用。请求体为 JSON 数组或 NDJSON（每行一个 JSON 对象），不校验 Content-Type（sendBeacon 发送字符串时为 text/plain）。每条数据单独校验和去重，返回逐条结果
+// @Tags 统计
+// @Accept plain
+// @Produce json
+// @Param items body []database.VisitRecord true "访问记录和事件列表"
+// @Success 200 {object} response.BatchResult "成功"
+// @Router /stats/visits/batch [post]
+func RecordBatch(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, batchMaxBytes))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": e.INVALID_PARAMS, "msg": "Request body too large", "data": gin.H{}})
+		return
+	}
+
+	items, err := splitBatch(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+
+	res := response.BatchResult{Total: len(items), Items: make([]response.BatchItemResult, 0, len(items))}
+	for i, raw := range items {
+		item := recordBatchItem(c, raw)
+		item.Index = i
+		if item.Recorded {
+			res.Recorded++
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// splitBatch 把请求体拆分为单条 JSON，支持 JSON 数组和 NDJSON
+func splitBatch(body []byte) ([]json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, errors.New("Empty batch")
+	}
+
+	var items []json.RawMessage
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, errors.New("Invalid JSON array")
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 0, 4096), batchMaxBytes)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			items = append(items, json.RawMessage(append([]byte(nil), line...)))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, errors.New("Invalid NDJSON")
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("Empty batch")
+	}
+	if len(items) > batchMaxItems {
+		return nil, errors.New("Too many items in batch")
+	}
+	return items, nil
+}
+
+// recordBatchItem 校验并记录单条数据，单条失败不影响其他数据
+func recordBatchItem(c *gin.Context, raw json.RawMessage) response.BatchItemResult {
+	var item batchItem
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return invalidItem("", "invalid JSON")
+	}
+	if item.Type == "" {
+		item.Type = batchTypeVisit
+	}
+
+	switch item.Type {
+	case batchTypeVisit:
+		var visitRecord database.VisitRecord
+		if err := json.Unmarshal(raw, &visitRecord); err != nil {
+			return invalidItem(item.Type, "invalid visit record")
+		}
+		if msg := validateVisit(&visitRecord); msg != "" {
+			return invalidItem(item.Type, msg)
+		}
+		recorded, reason, err := recordVisit(c, &visitRecord)
+		if err != nil {
+			return response.BatchItemResult{Type: item.Type, Reason: "error", Error: "failed to record visit"}
+		}
+		return response.BatchItemResult{Type: item.Type, Recorded: recorded, Reason: reason}
+	case batchTypeEvent:
+		var req eventRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return invalidItem(item.Type, "invalid event")
+		}
+		event, err := newEvent(c, &req)
+		if err != nil {
+			return invalidItem(item.Type, err.Error())
+		}
+		if err := saveEvent(event); err != nil {
+			return response.BatchItemResult{Type: item.Type, Reason: "error", Error: "failed to record event"}
+		}
+		return response.BatchItemResult{Type: item.Type, Recorded: true, Reason: eventRecorded}
+	case batchTypeEngagement:
+		var engagement database.Engagement
+		if err := json.Unmarshal(raw, &engagement); err != nil {
+			return invalidItem(item.Type, "invalid engagement")
+		}
+		if err := validateEngagement(&engagement); err != nil {
+			return invalidItem(item.Type, err.Error())
+		}
+		if err := saveEngagement(&engagement); err != nil {
+			return response.BatchItemResult{Type: item.Type, Reason: "error", Error: "failed to record engagement"}
+		}
+		liveTouch(c, engagement.SessionID, engagement.Page)
+		return response.BatchItemResult{Type: item.Type, Recorded: true, Reason: engagementRecorded}
+	default:
+		return invalidItem(item.Type, "unsupported type")
+	}
+}
+
+// validateVisit 批量上报的访问记录必须带页面和会话
+func validateVisit(record *database.VisitRecord) string {
+	switch {
+	case record.Page == "":
+		return "page is required"
+	case record.SessionID == "":
+		return "session_id is required"
+	case len(record.SessionID) > 100:
+		return "session_id is too long"
+	}
+	return ""
+}
+
+func invalidItem(typ, msg string) response.BatchItemResult {
+	return response.BatchItemResult{Type: typ, Reason: visitInvalid, Error: msg}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/response"
+)
+
+func TestSplitBatch(t *testing.T) {
+	tooMany := strings.Repeat(`{"page":"/"}`+"\n", batchMaxItems+1)
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr string
+	}{
+		{name: "json array", body: ` [{"page":"/a"}, {"page":"/b"}] `, want: []string{`{"page":"/a"}`, `{"page":"/b"}`}},
+		{name: "ndjson", body: "{\"page\":\"/a\"}\n\n  {\"page\":\"/b\"}  \n", want: []string{`{"page":"/a"}`, `{"page":"/b"}`}},
+		{name: "single ndjson line", body: `{"page":"/a"}`, want: []string{`{"page":"/a"}`}},
+		{name: "ndjson keeps invalid lines for per-item errors", body: "{\"page\":\"/a\"}\nnot json", want: []string{`{"page":"/a"}`, "not json"}},
+		{name: "empty body", body: "  \n ", wantErr: "Empty batch"},
+		{name: "empty array", body: "[]", wantErr: "Empty batch"},
+		{name: "broken array", body: `[{"page":"/a"}`, wantErr: "Invalid JSON array"},
+		{name: "too many items", body: tooMany, wantErr: "Too many items in batch"},
+		{name: "exactly the limit", body: strings.Repeat(`{"page":"/"}`+"\n", batchMaxItems)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := splitBatch([]byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				return
+			}
+			got := make([]string, len(items))
+			for i, item := range items {
+				got[i] = string(item)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("items = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordBatchRejectsInvalidItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/stats/visits/batch", RecordBatch)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/stats/visits/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`[{"page":"/` + strings.Repeat("a", batchMaxBytes) + `"}]`); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if w := post("[1,"); w.Code != http.StatusBadRequest {
+		t.Errorf("broken array status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	body := strings.Join([]string{
+		`not json`,
+		`{"type":"click","page":"/a","session_id":"s1"}`,
+		`{"session_id":"s1"}`,
+		`{"page":"/a"}`,
+		`{"page":"/a","session_id":"` + strings.Repeat("s", 101) + `"}`,
+	}, "\n")
+	w := post(body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var res struct {
+		Data response.BatchResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	wantErrors := []string{"invalid JSON", "unsupported type", "page is required", "session_id is required", "session_id is too long"}
+	if res.Data.Total != len(wantErrors) || res.Data.Recorded != 0 || len(res.Data.Items) != len(wantErrors) {
+		t.Fatalf("result = %+v", res.Data)
+	}
+	for i, item := range res.Data.Items {
+		if item.Index != i || item.Recorded || item.Reason != visitInvalid || item.Error != wantErrors[i] {
+			t.Errorf("item %d = %+v, want invalid %q", i, item, wantErrors[i])
+		}
+	}
+}
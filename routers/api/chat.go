@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/chat"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/ratelimit"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"gorm.io/gorm"
+)
+
+// chatResponder 生成助手回复，默认按配置创建，可通过 SetChatResponder 替换
+var chatResponder = chat.NewResponder()
+
+// chatLimiter 按客户端 IP 和会话限制发送消息的频率，每条用户消息都会调用一次回复生成器（可能是付费的 LLM 接口）
+var chatLimiter = ratelimit.New(setting.ChatRateLimit, time.Minute)
+
+// SetChatResponder 替换助手回复生成器（例如接入其他 LLM 后端或在测试中使用回显实现）
+func SetChatResponder(r chat.Responder) {
+	chatResponder = r
+}
+
+type chatMessagePayload struct {
+	Role    string `json:"role"`
+	Content string `json:"content" binding:"required"`
+	Type    string `json:"type"`
+}
+
+// CreateChatSession 创建聊天会话
+// @Summary 创建聊天会话
+// @Tags 聊天
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /chat/sessions [post]
+func CreateChatSession(c *gin.Context) {
+	session, err := database.CreateChatSession(requestIP(c), c.GetHeader("User-Agent"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to create chat session", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": session})
+}
+
+// GetChatMessages 获取会话历史
+// @Summary 获取会话历史
+// @Description 按时间正序分页返回会话消息
+// @Tags 聊天
+// @Produce json
+// @Param session_id path string true "会话ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /chat/sessions/{session_id}/messages [get]
+func GetChatMessages(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	if _, err := database.GetChatSession(sessionID); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	result, err := database.GetChatMessages(sessionID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get chat messages", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": result})
+}
+
+// AddChatMessage 追加消息
+// @Summary 追加消息
+// @Description 追加一条消息，role 为 user（默认）时由回复生成器产生并保存助手回复。同一客户端 IP、同一会话每分钟最多 CHAT_RATE_LIMIT 条，超出时返回 429
+// @Tags 聊天
+// @Accept json
+// @Produce json
+// @Param session_id path string true "会话ID"
+// @Param body body chatMessagePayload true "消息内容"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /chat/sessions/{session_id}/messages [post]
+func AddChatMessage(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	if _, err := database.GetChatSession(sessionID); err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	var payload chatMessagePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "content is required", "data": gin.H{}})
+		return
+	}
+	if payload.Role == "" {
+		payload.Role = chat.RoleUser
+	}
+	if payload.Role != chat.RoleUser && payload.Role != chat.RoleAssistant {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "role must be user or assistant", "data": gin.H{}})
+		return
+	}
+	if setting.ChatMaxLength > 0 && utf8.RuneCountInString(payload.Content) > setting.ChatMaxLength {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "content is too long", "data": gin.H{}})
+		return
+	}
+	// IP 和会话分别计数，更换会话或更换 IP 都不能绕过限制
+	if !chatLimiter.Allow("ip:"+requestIP(c)) || !chatLimiter.Allow("session:"+sessionID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": e.ERROR_CHAT_RATE_LIMIT, "msg": e.GetMsg(e.ERROR_CHAT_RATE_LIMIT), "data": gin.H{}})
+		return
+	}
+
+	message := database.ChatMessage{
+		SessionID: sessionID,
+		Role:      payload.Role,
+		Content:   payload.Content,
+		Type:      payload.Type,
+	}
+	if err := database.AddChatMessage(&message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to add chat message", "data": gin.H{}})
+		return
+	}
+
+	data := gin.H{"message": message}
+	if payload.Role != chat.RoleUser {
+		c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": data})
+		return
+	}
+
+	// 携带最近的历史消息生成助手回复
+	recent, err := database.GetRecentChatMessages(sessionID, setting.ChatHistoryLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get chat messages", "data": gin.H{}})
+		return
+	}
+	history := make([]chat.Message, 0, len(recent))
+	for _, m := range recent {
+		history = append(history, chat.Message{Role: m.Role, Content: m.Content})
+	}
+
+	content, err := chatResponder.Reply(c.Request.Context(), history)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": e.ERROR_CHAT_REPLY, "msg": e.GetMsg(e.ERROR_CHAT_REPLY), "data": data})
+		return
+	}
+
+	reply := database.ChatMessage{
+		SessionID: sessionID,
+		Role:      chat.RoleAssistant,
+		Content:   content,
+		Type:      payload.Type,
+	}
+	if err := database.AddChatMessage(&reply); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to add chat message", "data": data})
+		return
+	}
+	data["reply"] = reply
+
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": data})
+}
+
+// DeleteChatSession 删除会话
+// @Summary 删除会话
+// @Description 删除会话及其全部消息
+// @Tags 聊天
+// @Produce json
+// @Param session_id path string true "会话ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /chat/sessions/{session_id} [delete]
+func DeleteChatSession(c *gin.Context) {
+	if err := database.DeleteChatSession(c.Param("session_id")); err != nil {
+		respondChatError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"deleted": true}})
+}
+
+func respondChatError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": e.ERROR_NOT_EXIST_CHAT, "msg": e.GetMsg(e.ERROR_NOT_EXIST_CHAT), "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to access chat session", "data": gin.H{}})
+}
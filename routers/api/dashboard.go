@@ -18,6 +18,8 @@ import (
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
 // @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "访问时间显示的时区（如 Asia/Shanghai），默认为报表时区"
 // @Success 200 {object} map[string]interface{} "成功"
 // @Router /stats/records [get]
 func GetVisitRecords(c *gin.Context) {
@@ -25,9 +27,14 @@ func GetVisitRecords(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	language := c.Query("language")
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
 
 	// 调用模型层函数
-	result, err := database.GetVisitRecords(page, pageSize, language)
+	result, err := database.GetVisitRecords(page, pageSize, language, includeBots(c), loc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": e.ERROR,
@@ -50,11 +57,19 @@ func GetVisitRecords(c *gin.Context) {
 // @Tags Dashboard
 // @Accept json
 // @Produce json
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "时区（如 Asia/Shanghai），决定“今天”的范围，默认为报表时区"
 // @Success 200 {object} map[string]interface{} "成功"
 // @Router /stats/overview [get]
 func GetVisitOverview(c *gin.Context) {
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
+
 	// 调用模型层函数
-	result, err := database.GetVisitOverview()
+	result, err := database.GetVisitOverview(includeBots(c), loc)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": e.ERROR,
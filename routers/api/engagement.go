@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// engagementMaxDuration 单次访问停留时长上限（秒），超出部分视为挂机
+const engagementMaxDuration = 4 * 3600
+
+// RecordEngagement 上报页面停留时长和滚动深度
+// @Summary 上报页面停留时长和滚动深度
+// @Description 页面心跳或卸载时上报累计停留秒数和最大滚动深度，按 session_id + page 更新当天的访问记录，多次上报取最大值。可用 navigator.sendBeacon 发送
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param engagement body database.Engagement true "阅读数据"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/engagement [post]
+func RecordEngagement(c *gin.Context) {
+	var engagement database.Engagement
+	if err := c.ShouldBindJSON(&engagement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid JSON data", "data": gin.H{}})
+		return
+	}
+	if err := validateEngagement(&engagement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err := saveEngagement(&engagement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to record engagement", "data": gin.H{}})
+		return
+	}
+	liveTouch(c, engagement.SessionID, engagement.Page)
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"recorded": true}})
+}
+
+// validateEngagement 校验必填字段，停留时长和滚动深度截断到合理范围
+func validateEngagement(engagement *database.Engagement) error {
+	switch {
+	case engagement.SessionID == "":
+		return errors.New("session_id is required")
+	case len(engagement.SessionID) > 100:
+		return errors.New("session_id is too long")
+	case engagement.Page == "":
+		return errors.New("page is required")
+	case engagement.Duration < 0 || engagement.ScrollDepth < 0:
+		return errors.New("duration and scroll_depth must not be negative")
+	}
+	engagement.Duration = min(engagement.Duration, engagementMaxDuration)
+	engagement.ScrollDepth = min(engagement.ScrollDepth, 100)
+	return nil
+}
+
+// GetPageEngagement 获取页面阅读数据
+// @Summary 获取页面阅读数据
+// @Description 按访问量降序返回页面的平均停留时长、跳出率和读完率（滚动深度达到 90%）
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/engagement [get]
+func GetPageEngagement(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	stats, err := database.GetPageEngagement(limit, start, end, language, includeBots(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get engagement", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"pages": stats}})
+}
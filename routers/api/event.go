@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/botdetect"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/geoip"
+	"github.com/webbleen/go-gin/pkg/referrer"
+	"github.com/webbleen/go-gin/pkg/useragent"
+)
+
+// 事件上报的限制
+const (
+	eventMaxProperties     = 20
+	eventMaxPropertiesSize = 2000
+)
+
+// eventRequest 事件上报参数，properties 为任意 JSON 对象
+type eventRequest struct {
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+	Value      float64                `json:"value"`
+	Page       string                 `json:"page"`
+	SessionID  string                 `json:"session_id"`
+	Language   string                 `json:"language"`
+	Webdriver  bool                   `json:"webdriver"`
+}
+
+// RecordEvent 记录自定义事件
+// @Summary 记录自定义事件
+// @Description 记录页面浏览以外的事件，如外链点击、代码复制、站内搜索；properties 为 JSON 对象，可按其中的字段分组统计。事件不去重
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param event body eventRequest true "事件"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/event [post]
+func RecordEvent(c *gin.Context) {
+	var req eventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid JSON data", "data": gin.H{}})
+		return
+	}
+
+	event, err := newEvent(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err := saveEvent(event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to record event", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"recorded": true}})
+}
+
+// newEvent 校验上报参数并补全服务端信息（IP、设备、国家、机器人标记）
+func newEvent(c *gin.Context, req *eventRequest) (*database.Event, error) {
+	switch {
+	case req.Name == "":
+		return nil, errors.New("name is required")
+	case len(req.Name) > 100:
+		return nil, errors.New("name is too long")
+	case len(req.SessionID) > 100:
+		return nil, errors.New("session_id is too long")
+	case len(req.Properties) > eventMaxProperties:
+		return nil, errors.New("too many properties")
+	}
+
+	event := &database.Event{
+		Name:      req.Name,
+		Value:     req.Value,
+		Page:      req.Page,
+		SessionID: req.SessionID,
+		Language:  req.Language,
+	}
+	// 站内搜索事件的关键词与访问记录中的关键词同样规范化，便于对照统计
+	if term, ok := req.Properties["term"].(string); ok && req.Name == database.SearchEvent {
+		req.Properties["term"] = referrer.NormalizeTerm(term)
+	}
+	if len(req.Properties) > 0 {
+		props, err := json.Marshal(req.Properties)
+		if err != nil {
+			return nil, errors.New("invalid properties")
+		}
+		if len(props) > eventMaxPropertiesSize {
+			return nil, errors.New("properties are too large")
+		}
+		event.Properties = string(props)
+	}
+
+	event.IP = requestIP(c)
+	ua := c.GetHeader("User-Agent")
+	parsed := useragent.Parse(ua)
+	event.Device = parsed.Device
+	result := botdetect.Detect(botdetect.Signals{
+		UserAgent:      ua,
+		Parsed:         parsed,
+		IP:             event.IP,
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		Webdriver:      req.Webdriver,
+	})
+	event.IsBot = result.IsBot
+	if result.IsBot && event.Device == "" {
+		event.Device = useragent.DeviceBot
+	}
+	if loc, ok := geoip.Lookup(event.IP); ok {
+		event.Country = loc.Country
+	}
+	return event, nil
+}
+
+// GetTopEvents 获取热门事件
+// @Summary 获取热门事件
+// @Description 按次数降序返回事件名称
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/events [get]
+func GetTopEvents(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	stats, err := database.GetTopEvents(limit, start, end, language, includeBots(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get events", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"events": stats}})
+}
+
+// GetEventStats 获取单个事件的趋势
+// @Summary 获取单个事件的趋势
+// @Description 返回最近N天该事件的次数、独立会话和 value 合计；指定 property 时同时按该属性的取值分组统计
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param name path string true "事件名称"
+// @Param days query int false "天数" default(30)
+// @Param property query string false "分组属性名（字母、数字、下划线、连字符）"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.EventStatsResult "成功"
+// @Router /stats/events/{name} [get]
+func GetEventStats(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			days = n
+		}
+	}
+	language := c.Query("language")
+
+	res, err := database.GetEventStats(c.Param("name"), c.Query("property"), days, language, includeBots(c))
+	if errors.Is(err, database.ErrInvalidProperty) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid property name", "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get event stats", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
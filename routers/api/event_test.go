@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+)
+
+func TestNewEvent(t *testing.T) {
+	props := func(n int) map[string]interface{} {
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			m[strings.Repeat("k", i+1)] = i
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		req     eventRequest
+		wantErr string
+	}{
+		{name: "minimal", req: eventRequest{Name: "copy_code"}},
+		{name: "max properties", req: eventRequest{Name: "copy_code", Properties: props(eventMaxProperties)}},
+		{name: "missing name", req: eventRequest{}, wantErr: "name is required"},
+		{name: "long name", req: eventRequest{Name: strings.Repeat("n", 101)}, wantErr: "name is too long"},
+		{name: "long session", req: eventRequest{Name: "copy_code", SessionID: strings.Repeat("s", 101)}, wantErr: "session_id is too long"},
+		{name: "too many properties", req: eventRequest{Name: "copy_code", Properties: props(eventMaxProperties + 1)}, wantErr: "too many properties"},
+		{
+			name:    "properties too large",
+			req:     eventRequest{Name: "copy_code", Properties: map[string]interface{}{"code": strings.Repeat("x", eventMaxPropertiesSize)}},
+			wantErr: "properties are too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/stats/event", nil)
+			c.Request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+			c.Request.Header.Set("Accept-Language", "en-US")
+
+			event, err := newEvent(c, &tt.req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Name != tt.req.Name || event.Device != "desktop" || event.IsBot {
+				t.Errorf("event = %+v", event)
+			}
+			if len(tt.req.Properties) > 0 && !strings.HasPrefix(event.Properties, "{") {
+				t.Errorf("properties = %q, want a JSON object", event.Properties)
+			}
+		})
+	}
+}
+
+func TestNewEventNormalizesSearchTerm(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/stats/event", nil)
+
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		want  string
+	}{
+		{database.SearchEvent, map[string]interface{}{"term": "  Hugo \t Themes ", "results": 0}, `{"results":0,"term":"hugo themes"}`},
+		// 其他事件的 term 属性原样保存
+		{"copy_code", map[string]interface{}{"term": "  Hugo "}, `{"term":"  Hugo "}`},
+		{database.SearchEvent, map[string]interface{}{"term": 42}, `{"term":42}`},
+	}
+	for _, tt := range tests {
+		event, err := newEvent(c, &eventRequest{Name: tt.name, Properties: tt.props})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Properties != tt.want {
+			t.Errorf("%s properties = %s, want %s", tt.name, event.Properties, tt.want)
+		}
+	}
+}
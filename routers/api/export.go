@@ -17,14 +17,21 @@ import (
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(100)
 // @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "时间列的时区（如 Asia/Shanghai），默认为报表时区"
 // @Success 200 {string} string "CSV 文件"
 // @Router /stats/export [get]
 func ExportVisitRecords(c *gin.Context) {
     page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
     pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "100"))
     language := c.Query("language")
+    loc, err := requestLocation(c)
+    if err != nil {
+        c.String(http.StatusBadRequest, "invalid tz")
+        return
+    }
 
-    result, err := database.GetVisitRecords(page, pageSize, language)
+    result, err := database.GetVisitRecords(page, pageSize, language, includeBots(c), loc)
     if err != nil {
         c.String(http.StatusInternalServerError, "failed to query records")
         return
@@ -35,7 +42,7 @@ func ExportVisitRecords(c *gin.Context) {
 
     writer := csv.NewWriter(c.Writer)
     // 表头
-    _ = writer.Write([]string{"id", "ip", "user_agent", "referer", "page", "session_id", "country", "city", "device", "browser", "os", "language", "created_on", "modified_on"})
+    _ = writer.Write([]string{"id", "ip", "user_agent", "referer", "page", "session_id", "country", "city", "device", "browser", "os", "language", "browser_version", "os_version", "is_bot", "bot_name", "referrer_domain", "channel", "utm_source", "utm_medium", "utm_campaign", "search_term", "search_type", "duration", "scroll_depth", "created_on", "modified_on"})
 
     for _, r := range result.Records {
         _ = writer.Write([]string{
@@ -51,6 +58,19 @@ func ExportVisitRecords(c *gin.Context) {
             r.Browser,
             r.OS,
             r.Language,
+            r.BrowserVersion,
+            r.OSVersion,
+            strconv.FormatBool(r.IsBot),
+            r.BotName,
+            r.ReferrerDomain,
+            r.Channel,
+            r.UTMSource,
+            r.UTMMedium,
+            r.UTMCampaign,
+            r.SearchTerm,
+            r.SearchType,
+            strconv.Itoa(r.Duration),
+            strconv.Itoa(r.ScrollDepth),
             r.CreatedOn,
             r.ModifiedOn,
         })
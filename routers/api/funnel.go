@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// GetFunnel 获取漏斗转化
+// @Summary 获取漏斗转化
+// @Description 按会话统计依次到达每个步骤的会话数、流失数和转化率。steps 为逗号分隔的页面规则（最多 10 个），* 匹配任意字符，如 /,/posts/*,/subscribe；步骤需按顺序出现，中间可以有其他页面。日期范围最多 90 天
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param steps query string true "逗号分隔的页面规则"
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.FunnelResult "成功"
+// @Router /stats/funnel [get]
+func GetFunnel(c *gin.Context) {
+	var steps []string
+	for _, step := range strings.Split(c.Query("steps"), ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	res, err := database.GetFunnel(steps, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrInvalidFunnel) || errors.Is(err, database.ErrSessionRange) || errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get funnel", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// GetPaths 获取从某个页面出发的常见路径
+// @Summary 获取从某个页面出发的常见路径
+// @Description 回答“读者看完这篇文章后去了哪里”：统计会话中访问 from 之后依次访问的 depth 个页面，按次数返回前 limit 条路径。from 支持 * 通配。日期范围最多 90 天
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param from query string true "起始页面"
+// @Param depth query int false "路径深度（1-5）" default(3)
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.PathResult "成功"
+// @Router /stats/paths [get]
+func GetPaths(c *gin.Context) {
+	from := c.Query("from")
+	if from == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "from is required", "data": gin.H{}})
+		return
+	}
+	depth := 3
+	if v := c.Query("depth"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			depth = n
+		}
+	}
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	res, err := database.GetPaths(from, depth, limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrSessionRange) || errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get paths", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
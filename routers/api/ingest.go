@@ -0,0 +1,184 @@
+package api
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/ingest"
+	"github.com/webbleen/go-gin/pkg/setting"
+)
+
+// 访问记录、事件、阅读数据写入队列和当日访问去重集合，INGEST_ASYNC 关闭时为 nil
+var (
+	visitQueue      *ingest.Queue[*database.VisitRecord]
+	eventQueue      *ingest.Queue[*database.Event]
+	engagementQueue *ingest.Queue[*database.Engagement]
+	visitDedupe     *ingest.Dedupe
+)
+
+// engagementPendingTTL 找不到访问记录的阅读数据最长暂存时间，访问记录可能还在队列或 spool 中
+const engagementPendingTTL = time.Hour
+
+var (
+	pendingMu          sync.Mutex
+	pendingEngagements []*database.Engagement
+)
+
+// StartIngest 启动访问记录、事件和阅读数据的异步写入队列，并从数据库加载今日已记录的访问用于去重
+func StartIngest() {
+	if !setting.IngestAsync {
+		return
+	}
+
+	visitDedupe = ingest.NewDedupe(setting.IngestDedupeSize, database.ReportLocation())
+	if database.DB != nil {
+		pages, err := database.GetVisitedPagesToday()
+		if err != nil {
+			log.Printf("加载今日访问记录失败: %v", err)
+		}
+		for _, p := range pages {
+			visitDedupe.Add(visitKey(p.SessionID, p.Page))
+		}
+	}
+
+	cfg := ingest.Config{
+		QueueSize:     setting.IngestQueueSize,
+		BatchSize:     setting.IngestBatchSize,
+		FlushInterval: setting.IngestFlushInterval,
+		Overflow:      setting.IngestOverflow,
+		SpoolDir:      setting.IngestSpoolDir,
+		SpoolMaxBytes: setting.IngestSpoolMaxBytes,
+		Ping:          database.Ping,
+	}
+	visitQueue = ingest.New("visit_record", cfg, flushVisits)
+	eventQueue = ingest.New("event", cfg, database.AddEvents)
+	engagementQueue = ingest.New("engagement", cfg, flushEngagements)
+}
+
+// flushVisits 写入访问记录，成功后重试等待这些访问记录的阅读数据
+func flushVisits(records []*database.VisitRecord) error {
+	if err := database.AddVisitRecords(records); err != nil {
+		return err
+	}
+	retryPendingEngagements()
+	return nil
+}
+
+// flushEngagements 更新阅读数据；访问记录还没写入（先于访问记录出队或仍在 spool 中）的上报暂存，
+// 在下一次访问记录写入后重试
+func flushEngagements(engagements []*database.Engagement) error {
+	unmatched, err := database.UpdateEngagements(engagements)
+	if err != nil {
+		return err
+	}
+	holdEngagements(unmatched)
+	return nil
+}
+
+// retryPendingEngagements 重试暂存的阅读数据，仍找不到访问记录的继续暂存
+func retryPendingEngagements() {
+	pendingMu.Lock()
+	pending := pendingEngagements
+	pendingEngagements = nil
+	pendingMu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	unmatched, err := database.UpdateEngagements(pending)
+	if err != nil {
+		log.Printf("更新暂存的阅读数据失败: %v", err)
+		unmatched = pending
+	}
+	holdEngagements(unmatched)
+}
+
+// holdEngagements 暂存找不到访问记录的阅读数据，超过 engagementPendingTTL 或队列容量的部分丢弃
+func holdEngagements(engagements []*database.Engagement) {
+	if len(engagements) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-engagementPendingTTL)
+
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	kept := make([]*database.Engagement, 0, len(pendingEngagements)+len(engagements))
+	for _, e := range append(pendingEngagements, engagements...) {
+		if e.Time.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	if limit := max(setting.IngestQueueSize, 1); len(kept) > limit {
+		kept = kept[len(kept)-limit:]
+	}
+	pendingEngagements = kept
+}
+
+// CloseIngest 停止接收并写完队列中的数据，进程退出前调用
+func CloseIngest(timeout time.Duration) {
+	if visitQueue == nil {
+		return
+	}
+	if err := visitQueue.Close(timeout); err != nil {
+		log.Printf("访问记录队列未写完: %v", err)
+	}
+	if err := eventQueue.Close(timeout); err != nil {
+		log.Printf("事件队列未写完: %v", err)
+	}
+	if err := engagementQueue.Close(timeout); err != nil {
+		log.Printf("阅读数据队列未写完: %v", err)
+	}
+}
+
+// visitKey 当日访问去重的 key，page 为 ParseURL 解析后的路径
+func visitKey(sessionID, page string) string {
+	return sessionID + "\x00" + page
+}
+
+// visitRecorded 今日是否已记录过该会话对该页面的访问
+// 异步模式下优先查内存集合（同时登记本次访问），集合已满时回退到数据库查询
+func visitRecorded(sessionID, page string) bool {
+	if visitDedupe == nil {
+		return database.CheckVisitExists(sessionID, page)
+	}
+	seen, full := visitDedupe.Add(visitKey(sessionID, database.NormalizePage(page)))
+	if full {
+		return database.CheckVisitExists(sessionID, page)
+	}
+	return seen
+}
+
+// saveVisitRecord 保存访问记录：异步模式下入队，否则同步写库
+func saveVisitRecord(record *database.VisitRecord) error {
+	if visitQueue == nil {
+		return database.AddVisitRecord(record)
+	}
+	database.PrepareVisitRecord(record)
+	if !visitQueue.Submit(record) {
+		// 被丢弃的访问允许稍后重新记录
+		visitDedupe.Remove(visitKey(record.SessionID, record.Page))
+	}
+	return nil
+}
+
+// saveEvent 保存事件：异步模式下入队，否则同步写库
+func saveEvent(event *database.Event) error {
+	if eventQueue == nil {
+		return database.AddEvent(event)
+	}
+	database.PrepareEvent(event)
+	eventQueue.Submit(event)
+	return nil
+}
+
+// saveEngagement 保存阅读数据：异步模式下入队，否则同步更新
+func saveEngagement(engagement *database.Engagement) error {
+	if engagementQueue == nil {
+		return database.UpdateEngagement(engagement)
+	}
+	database.PrepareEngagement(engagement)
+	engagementQueue.Submit(engagement)
+	return nil
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/botdetect"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/live"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/useragent"
+)
+
+// 实时访客推送的限制
+const (
+	liveMaxVisitors = 100000           // 同时跟踪的在线会话上限
+	liveMaxStreams  = 100              // 同时连接的 SSE 客户端上限
+	liveMaxPerIP    = 3                // 同一客户端 IP 同时连接的 SSE 上限，避免单个客户端占满全部连接
+	liveThrottle    = time.Second      // 数据变化时两次推送的最小间隔
+	liveKeepAlive   = 15 * time.Second // 没有变化时的推送间隔，同时刷新过期的在线访客
+)
+
+var (
+	liveTracker   = live.NewTracker(live.DefaultWindow, live.DefaultMinutes, liveMaxVisitors)
+	liveStreams   atomic.Int32
+	liveStreamsMu sync.Mutex
+	liveStreamsBy = make(map[string]int) // 按客户端 IP 统计的 SSE 连接数
+	liveDone      = make(chan struct{})
+	liveCloseOnce sync.Once
+)
+
+// CloseLive 结束所有 SSE 连接，进程退出前调用，避免长连接阻塞平滑退出
+func CloseLive() {
+	liveCloseOnce.Do(func() { close(liveDone) })
+}
+
+// acquireLiveStream 占用一个 SSE 连接名额，超出全局或单个 IP 的上限时返回 false
+func acquireLiveStream(ip string) bool {
+	liveStreamsMu.Lock()
+	defer liveStreamsMu.Unlock()
+	if liveStreamsBy[ip] >= liveMaxPerIP {
+		return false
+	}
+	if liveStreams.Add(1) > liveMaxStreams {
+		liveStreams.Add(-1)
+		return false
+	}
+	liveStreamsBy[ip]++
+	return true
+}
+
+// releaseLiveStream 释放 acquireLiveStream 占用的名额
+func releaseLiveStream(ip string) {
+	liveStreamsMu.Lock()
+	defer liveStreamsMu.Unlock()
+	liveStreams.Add(-1)
+	if liveStreamsBy[ip]--; liveStreamsBy[ip] <= 0 {
+		delete(liveStreamsBy, ip)
+	}
+}
+
+// liveVisit 新记录的真人访问计入实时访客
+func liveVisit(record *database.VisitRecord) {
+	if !record.IsBot {
+		liveTracker.Visit(record.SessionID, database.ParseURL(record.Page))
+	}
+}
+
+// liveTouch 重复访问和阅读心跳只刷新已在线会话的状态，机器人请求不刷新
+func liveTouch(c *gin.Context, sessionID, page string) {
+	ua := c.GetHeader("User-Agent")
+	result := botdetect.Detect(botdetect.Signals{
+		UserAgent:      ua,
+		Parsed:         useragent.Parse(ua),
+		IP:             requestIP(c),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+	})
+	if result.IsBot {
+		return
+	}
+	liveTracker.Touch(sessionID, database.ParseURL(page))
+}
+
+// liveLimit 解析返回的页面数量
+func liveLimit(c *gin.Context) int {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	return limit
+}
+
+// GetLive 获取实时访客快照
+// @Summary 获取实时访客快照
+// @Description 返回最近 5 分钟内有访问或阅读心跳的在线访客数、他们所在的页面，以及最近 30 分钟每分钟的访问量。数据保存在进程内存中，重启后清空，不含机器人流量
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "页面返回数量" default(10)
+// @Param tz query string false "时间显示的时区（如 Asia/Shanghai），默认为报表时区"
+// @Success 200 {object} live.Snapshot "成功"
+// @Router /stats/live/snapshot [get]
+func GetLive(c *gin.Context) {
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": liveTracker.Snapshot(liveLimit(c), loc)})
+}
+
+// StreamLive 通过 SSE 推送实时访客
+// @Summary 通过 SSE 推送实时访客
+// @Description Server-Sent Events 流，连接后立即推送一次 live 事件，之后数据变化时（最多每秒一次）或每 15 秒推送一次，数据结构与 /stats/live/snapshot 相同。连接在写入超时前主动结束，EventSource 会自动重连
+// @Tags 统计
+// @Produce text/event-stream
+// @Param limit query int false "页面返回数量" default(10)
+// @Param tz query string false "时间显示的时区（如 Asia/Shanghai），默认为报表时区"
+// @Success 200 {object} live.Snapshot "live 事件"
+// @Router /stats/live [get]
+func StreamLive(c *gin.Context) {
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
+	ip := requestIP(c)
+	if !acquireLiveStream(ip) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": e.ERROR, "msg": "Too many live connections", "data": gin.H{}})
+		return
+	}
+	defer releaseLiveStream(ip)
+
+	limit := liveLimit(c)
+	// 服务器设置了写入超时，超时后连接会被直接断开，因此提前结束并让客户端重连
+	var deadline <-chan time.Time
+	if setting.WriteTimeout > 0 {
+		timer := time.NewTimer(max(setting.WriteTimeout-5*time.Second, setting.WriteTimeout/2))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	changed := liveTracker.Changed()
+	c.SSEvent("live", liveTracker.Snapshot(limit, loc))
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-liveDone:
+			return false
+		case <-deadline:
+			return false
+		case <-keepAlive.C:
+		case <-changed:
+			// 合并一秒内的多次变化
+			select {
+			case <-ctx.Done():
+				return false
+			case <-liveDone:
+				return false
+			case <-time.After(liveThrottle):
+			}
+		}
+		changed = liveTracker.Changed()
+		c.SSEvent("live", liveTracker.Snapshot(limit, loc))
+		return true
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/pkg/live"
+)
+
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestLiveTouch(t *testing.T) {
+	original := liveTracker
+	liveTracker = live.NewTracker(time.Minute, 1, 0)
+	t.Cleanup(func() { liveTracker = original })
+	liveTracker.Visit("s1", "/a/")
+
+	touch := func(ua, session, page string) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/stats/engagement", nil)
+		c.Request.Header.Set("User-Agent", ua)
+		c.Request.Header.Set("Accept-Language", "en-US")
+		liveTouch(c, session, page)
+	}
+	pages := func() []live.PageCount {
+		return liveTracker.Snapshot(10, time.Local).Pages
+	}
+
+	// 机器人请求和未在线的会话都不刷新
+	touch("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "s1", "/b/")
+	touch(chromeUA, "s2", "/b/")
+	if got, want := pages(), []live.PageCount{{Page: "/a/", Visitors: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %+v, want %+v", got, want)
+	}
+
+	touch(chromeUA, "s1", "/b/")
+	if got, want := pages(), []live.PageCount{{Page: "/b/", Visitors: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages after a human heartbeat = %+v, want %+v", got, want)
+	}
+}
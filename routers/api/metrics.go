@@ -25,11 +25,21 @@ var (
         },
         []string{"method", "path"},
     )
+
+    // 客户端 IP 解析为私有地址的次数，result 为 private / egress / egress_unavailable
+    privateIPFallbackTotal = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "client_ip_private_fallback_total",
+            Help: "Number of client IP resolutions that yielded a private address, by outcome",
+        },
+        []string{"result"},
+    )
 )
 
 func init() {
     prometheus.MustRegister(httpRequestsTotal)
     prometheus.MustRegister(httpRequestDuration)
+    prometheus.MustRegister(privateIPFallbackTotal)
 }
 
 // PrometheusHandler 暴露 /metrics
@@ -10,6 +10,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/geoip"
+	"github.com/webbleen/go-gin/pkg/iputil"
+	"github.com/webbleen/go-gin/pkg/setting"
 )
 
 // BingResponse 必应壁纸响应结构
@@ -192,7 +195,7 @@ func GetFavicon(c *gin.Context) {
 
 // GetGeoLocation 获取地理位置信息
 // @Summary 获取地理位置信息
-// @Description 返回客户端的地理位置信息
+// @Description 返回客户端的地理位置信息，优先查询本地 GeoIP 数据库
 // @Tags 代理服务
 // @Accept json
 // @Produce json
@@ -202,86 +205,16 @@ func GetGeoLocation(c *gin.Context) {
 	// 获取真实的客户端IP
 	clientIP := getRealClientIP(c)
 
-	// 尝试多个地理位置服务
-	geoServices := []struct {
-		name string
-		url  string
-	}{
-		{"ip-api.com", fmt.Sprintf("https://ip-api.com/json/%s", clientIP)},
-		{"ipinfo.io", fmt.Sprintf("https://ipinfo.io/%s/json", clientIP)},
-		{"ipapi.co", fmt.Sprintf("https://ipapi.co/%s/json/", clientIP)},
+	geoResp := GeoResponse{
+		Country: "Unknown",
+		City:    "Unknown",
+		Region:  "Unknown",
+		IP:      clientIP,
 	}
-
-	var geoResp GeoResponse
-
-	for _, service := range geoServices {
-		client := &http.Client{
-			Timeout: 10 * time.Second,
-		}
-
-		resp, err := client.Get(service.url)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			continue
-		}
-
-		var geoData map[string]interface{}
-		if err := json.Unmarshal(body, &geoData); err != nil {
-			continue
-		}
-
-		// 检查是否有错误（不同服务的错误字段不同）
-		if _, exists := geoData["error"]; exists {
-			continue
-		}
-		if status, exists := geoData["status"]; exists && status != "success" {
-			continue
-		}
-
-		// 根据不同的服务解析数据
-		switch service.name {
-		case "ipapi.co":
-			geoResp = GeoResponse{
-				Country: getString(geoData, "country_name"),
-				City:    getString(geoData, "city"),
-				Region:  getString(geoData, "region"),
-				IP:      clientIP,
-			}
-		case "ip-api.com":
-			geoResp = GeoResponse{
-				Country: getString(geoData, "country"),
-				City:    getString(geoData, "city"),
-				Region:  getString(geoData, "regionName"),
-				IP:      clientIP,
-			}
-		case "ipinfo.io":
-			geoResp = GeoResponse{
-				Country: getString(geoData, "country"),
-				City:    getString(geoData, "city"),
-				Region:  getString(geoData, "region"),
-				IP:      clientIP,
-			}
-		}
-
-		// 如果获取到了有效数据，跳出循环
-		if geoResp.Country != "" {
-			break
-		}
-	}
-
-	// 如果所有服务都失败了，返回默认值
-	if geoResp.Country == "" {
-		geoResp = GeoResponse{
-			Country: "Unknown",
-			City:    "Unknown",
-			Region:  "Unknown",
-			IP:      clientIP,
-		}
+	if loc, ok := geoip.Lookup(clientIP); ok {
+		geoResp.Country = loc.Country
+		geoResp.City = loc.City
+		geoResp.Region = loc.Region
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -315,123 +248,22 @@ func GetClientIP(c *gin.Context) {
 }
 
 // getRealClientIP 获取真实的客户端IP地址
+// 请求头只在直连对端属于可信代理时生效，见 TRUSTED_PROXIES / CLIENT_IP_HEADERS
+// 解析结果为私有地址时按 PRIVATE_IP_MODE 处理：private 如实返回，egress 返回缓存的服务器出口 IP
 func getRealClientIP(c *gin.Context) string {
-	// 按优先级检查各种可能的IP头
-	headers := []string{
-		"X-Forwarded-For",     // 最常用的代理头
-		"X-Real-IP",           // Nginx代理
-		"X-Client-IP",         // 其他代理
-		"CF-Connecting-IP",    // Cloudflare
-		"True-Client-IP",      // Cloudflare Enterprise
-		"X-Cluster-Client-IP", // 集群
-		"X-Forwarded",         // 其他代理
-		"Forwarded-For",       // RFC 7239
-		"Forwarded",           // RFC 7239
+	clientIP := requestIP(c)
+	if !iputil.IsPrivate(clientIP) {
+		return clientIP
 	}
 
-	for _, header := range headers {
-		ip := c.GetHeader(header)
-		if ip != "" {
-			// X-Forwarded-For 可能包含多个IP，取第一个
-			if strings.Contains(ip, ",") {
-				ips := strings.Split(ip, ",")
-				ip = strings.TrimSpace(ips[0])
-			}
-
-			// 验证IP格式
-			if isValidIP(ip) {
-				return ip
-			}
-		}
+	if setting.PrivateIPMode != "egress" {
+		privateIPFallbackTotal.WithLabelValues("private").Inc()
+		return clientIP
 	}
-
-	// 如果所有头都没有，使用Gin的ClientIP方法
-	clientIP := c.ClientIP()
-
-	// 如果获取到的是私有IP，尝试从外部API获取真实IP
-	if isPrivateIP(clientIP) {
-		realIP := getPublicIPFromExternalAPI()
-		if realIP != "" {
-			return realIP
-		}
+	if egressIP := iputil.EgressIP(); egressIP != "" {
+		privateIPFallbackTotal.WithLabelValues("egress").Inc()
+		return egressIP
 	}
-
+	privateIPFallbackTotal.WithLabelValues("egress_unavailable").Inc()
 	return clientIP
 }
-
-// isPrivateIP 检查是否为私有IP
-func isPrivateIP(ip string) bool {
-	return strings.HasPrefix(ip, "192.168.") ||
-		strings.HasPrefix(ip, "172.") ||
-		strings.HasPrefix(ip, "10.") ||
-		strings.HasPrefix(ip, "100.64.") ||
-		strings.HasPrefix(ip, "127.") ||
-		strings.HasPrefix(ip, "169.254.")
-}
-
-// getPublicIPFromExternalAPI 从外部API获取公网IP
-func getPublicIPFromExternalAPI() string {
-	ipServices := []string{
-		"https://api.ipify.org?format=text",
-		"https://ipv4.icanhazip.com",
-		"https://checkip.amazonaws.com",
-	}
-
-	for _, serviceURL := range ipServices {
-		client := &http.Client{
-			Timeout: 5 * time.Second,
-		}
-
-		resp, err := client.Get(serviceURL)
-		if err == nil {
-			defer resp.Body.Close()
-			body, err := io.ReadAll(resp.Body)
-			if err == nil && len(body) > 0 {
-				ip := strings.TrimSpace(string(body))
-				// 验证获取到的IP是否为有效的公网IP
-				if isValidIP(ip) && !isPrivateIP(ip) {
-					return ip
-				}
-			}
-		}
-	}
-
-	return ""
-}
-
-// isValidIP 验证IP地址格式
-func isValidIP(ip string) bool {
-	// 简单的IP格式验证
-	parts := strings.Split(ip, ".")
-	if len(parts) != 4 {
-		return false
-	}
-
-	for _, part := range parts {
-		if part == "" {
-			return false
-		}
-		// 检查是否为数字
-		for _, char := range part {
-			if char < '0' || char > '9' {
-				return false
-			}
-		}
-		// 检查范围
-		if len(part) > 3 {
-			return false
-		}
-	}
-
-	return true
-}
-
-// 辅助函数：从map中安全获取字符串值
-func getString(data map[string]interface{}, key string) string {
-	if value, exists := data[key]; exists {
-		if str, ok := value.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
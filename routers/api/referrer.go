@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// GetReferrers 获取来源统计
+// @Summary 获取来源统计
+// @Description 按渠道（direct、internal、search、social、email、referral）和外部来源域名统计访问量。带 utm_medium 的访问按推广参数声明的渠道统计
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "来源域名返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.ReferrerStatsResult "成功"
+// @Router /stats/referrers [get]
+func GetReferrers(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	res, err := database.GetReferrerStats(limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get referrers", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// GetCampaigns 获取推广活动统计
+// @Summary 获取推广活动统计
+// @Description 按页面 URL 中的 utm_source、utm_medium、utm_campaign 分组，按访问量降序返回访问量和会话数
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/campaigns [get]
+func GetCampaigns(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	campaigns, err := database.GetCampaignStats(limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get campaigns", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"campaigns": campaigns}})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/referrer"
+)
+
+// GetSearchTerms 获取热门搜索关键词
+// @Summary 获取热门搜索关键词
+// @Description 按次数降序返回搜索关键词，engine 来自搜索引擎来源 URL，site 来自站内搜索页面 URL（SITE_SEARCH_PARAMS 配置的参数）。关键词已转小写并合并空白
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param type query string false "来源：engine 或 site，为空时返回全部"
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/search [get]
+func GetSearchTerms(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	terms, err := database.GetSearchTerms(c.Query("type"), limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrInvalidSearchType) || errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get search terms", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"terms": terms}})
+}
+
+// GetZeroResultSearches 获取无结果的站内搜索
+// @Summary 获取无结果的站内搜索
+// @Description 统计前端上报的 search 事件中 properties.results 为 0 的关键词，按次数降序返回
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/search/zero-results [get]
+func GetZeroResultSearches(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	terms, err := database.GetZeroResultSearches(limit, start, end, language, includeBots(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get zero-result searches", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"terms": terms}})
+}
+
+// GetSearchTrend 获取搜索关键词趋势
+// @Summary 获取搜索关键词趋势
+// @Description 返回最近N天该关键词每天的搜索次数，关键词按入库规则规范化后匹配
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param term query string true "搜索关键词"
+// @Param type query string false "来源：engine 或 site，为空时统计全部"
+// @Param days query int false "天数" default(30)
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.SearchTrendResult "成功"
+// @Router /stats/search/trend [get]
+func GetSearchTrend(c *gin.Context) {
+	term := referrer.NormalizeTerm(c.Query("term"))
+	if term == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "term is required", "data": gin.H{}})
+		return
+	}
+	days := 30
+	if v := c.Query("days"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			days = n
+		}
+	}
+	language := c.Query("language")
+
+	res, err := database.GetSearchTrend(term, c.Query("type"), days, language, includeBots(c))
+	if errors.Is(err, database.ErrInvalidSearchType) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get search trend", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// GetSessionStats 获取会话概览
+// @Summary 获取会话概览
+// @Description 按 session_id 和访问时间重建会话（间隔超过 30 分钟视为新会话），返回会话数、平均时长、平均页数、跳出率、入口页和退出页。只覆盖保留期内的原始访问记录，未指定开始日期时分析最近 30 天，日期范围最多 90 天
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param limit query int false "入口页/退出页返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} response.SessionStatsResult "成功"
+// @Router /stats/sessions [get]
+func GetSessionStats(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	res, err := database.GetSessionStats(limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrSessionRange) || errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get sessions", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// GetSessionTransitions 获取会话内的页面跳转
+// @Summary 获取会话内的页面跳转
+// @Description 返回会话内最常见的“上一页 → 下一页”跳转，指定 from 时只返回从该页面出发的跳转。同一会话当天重复访问同一页面只记录一次，跳转按首次访问顺序计算
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param from query string false "起始页面"
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/sessions/transitions [get]
+func GetSessionTransitions(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+	language := c.Query("language")
+
+	transitions, err := database.GetSessionTransitions(c.Query("from"), limit, start, end, language, includeBots(c))
+	if errors.Is(err, database.ErrSessionRange) || errors.Is(err, database.ErrBeforeRetention) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get transitions", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"transitions": transitions}})
+}
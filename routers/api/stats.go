@@ -3,10 +3,18 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/botdetect"
 	"github.com/webbleen/go-gin/pkg/e"
+	"github.com/webbleen/go-gin/pkg/geoip"
+	"github.com/webbleen/go-gin/pkg/iputil"
+	"github.com/webbleen/go-gin/pkg/referrer"
+	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/useragent"
 )
 
 // GetVisitStats 获取访问统计概览
@@ -16,6 +24,8 @@ import (
 // @Accept json
 // @Produce json
 // @Param language query string false "语言代码" default("")
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "时区（如 Asia/Shanghai），决定“今天”的范围，默认为报表时区"
 // @Success 200 {object} map[string]interface{} "成功"
 // @Router /stats/visits [get]
 func GetVisitStats(c *gin.Context) {
@@ -23,25 +33,31 @@ func GetVisitStats(c *gin.Context) {
 
 	// 获取语言参数
 	language := c.Query("language")
+	bots := includeBots(c)
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
 
 	// 今日访问量
-	todayVisits := database.GetTodayVisits(language)
+	todayVisits := database.GetTodayVisits(language, bots, loc)
 	data["today_visits"] = todayVisits
 
 	// 累计访问量
-	totalVisits := database.GetTotalVisits(language)
+	totalVisits := database.GetTotalVisits(language, bots)
 	data["total_visits"] = totalVisits
 
 	// 今日独立访客
-	uniqueVisitorsToday := database.GetUniqueVisitorsToday(language)
+	uniqueVisitorsToday := database.GetUniqueVisitorsToday(language, bots, loc)
 	data["unique_visitors_today"] = uniqueVisitorsToday
 
 	// 今日独立会话数
-	todayUniqueSessions := database.GetTodayUniqueSessions(language)
+	todayUniqueSessions := database.GetTodayUniqueSessions(language, bots, loc)
 	data["today_unique_sessions"] = todayUniqueSessions
 
 	// 总独立会话数
-	totalUniqueSessions := database.GetTotalUniqueSessions(language)
+	totalUniqueSessions := database.GetTotalUniqueSessions(language, bots)
 	data["total_unique_sessions"] = totalUniqueSessions
 
 	// 添加语言信息
@@ -77,57 +93,195 @@ func RecordVisit(c *gin.Context) {
 		})
 		return
 	}
-
-	// 检查今日是否已记录过该页面的访问
-	if database.CheckVisitExists(visitRecord.SessionID, visitRecord.Page) {
-		c.JSON(http.StatusOK, gin.H{
-			"code": e.SUCCESS,
-			"msg":  "Visit already recorded today",
-			"data": map[string]interface{}{
-				"recorded": false,
-				"reason":   "already_exists",
-			},
-		})
+	if len(visitRecord.SessionID) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "session_id is too long", "data": gin.H{}})
 		return
 	}
 
-	// 设置服务器端信息
-	// 优先使用前端传递的真实外网IP，其次使用Netlify传递的真实IP，最后使用服务器检测的IP
-	if visitRecord.IP == "" {
-		// 尝试从Netlify请求头获取真实IP
-		if netlifyIP := c.GetHeader("X-Nf-Client-Connection-Ip"); netlifyIP != "" {
-			visitRecord.IP = netlifyIP
-		} else {
-			// 使用Gin的ClientIP()方法，它会自动检查X-Forwarded-For等标准请求头
-			visitRecord.IP = c.ClientIP()
-		}
+	recorded, reason, err := recordVisit(c, &visitRecord)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to record visit", "data": gin.H{}})
+		return
 	}
-	visitRecord.UserAgent = c.GetHeader("User-Agent")
-	visitRecord.Referer = c.GetHeader("Referer")
-
-	// 保存访问记录
-	database.AddVisitRecord(&visitRecord)
 
+	msg := e.GetMsg(e.SUCCESS)
+	if !recorded {
+		msg = "Visit already recorded today"
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"code": e.SUCCESS,
-		"msg":  e.GetMsg(e.SUCCESS),
+		"msg":  msg,
 		"data": map[string]interface{}{
-			"recorded": true,
-			"reason":   "new_visit",
+			"recorded": recorded,
+			"reason":   reason,
 		},
 	})
 }
 
+// 访问记录结果
+const (
+	visitNew     = "new_visit"
+	visitExists  = "already_exists"
+	visitInvalid = "invalid"
+	// 事件不去重，始终记录
+	eventRecorded = "new_event"
+	// 阅读数据更新已有的访问记录
+	engagementRecorded = "engagement_updated"
+)
+
+// recordVisit 补全服务端信息并保存一条访问记录，今日已记录过该页面时跳过
+func recordVisit(c *gin.Context, visitRecord *database.VisitRecord) (recorded bool, reason string, err error) {
+	// 去掉页面 URL 中的 utm_* 参数后再去重，同一页面不因推广参数不同重复记录
+	applyCampaign(visitRecord)
+
+	// 检查今日是否已记录过该页面的访问
+	if visitRecorded(visitRecord.SessionID, visitRecord.Page) {
+		liveTouch(c, visitRecord.SessionID, visitRecord.Page)
+		return false, visitExists, nil
+	}
+
+	// 设置服务器端信息
+	// IP 只取自连接和可信代理的请求头，忽略请求体中的 ip，避免伪造 IP 影响独立访客统计
+	visitRecord.IP = requestIP(c)
+	visitRecord.UserAgent = c.GetHeader("User-Agent")
+	// 来源只取前端传递的 document.referrer；请求头 Referer 是发起统计请求的页面本身，
+	// 不能作为来源，未传递时记为 direct
+	applyReferrer(c, visitRecord)
+	applyUserAgent(visitRecord)
+	applyBotDetection(c, visitRecord)
+	applyGeoIP(visitRecord)
+
+	// 保存访问记录
+	if err := saveVisitRecord(visitRecord); err != nil {
+		return false, "", err
+	}
+	liveVisit(visitRecord)
+	return true, visitNew, nil
+}
+
+// requestIP 获取请求方IP，由可信代理配置决定读取哪些请求头
+func requestIP(c *gin.Context) string {
+	return iputil.ClientIP(c.Request)
+}
+
+// applyCampaign 提取页面 URL 中的 UTM 参数并从页面中去掉
+func applyCampaign(record *database.VisitRecord) {
+	campaign, page := referrer.ParseCampaign(record.Page)
+	record.Page = page
+	record.UTMSource = truncate(campaign.Source, 100)
+	record.UTMMedium = truncate(campaign.Medium, 100)
+	record.UTMCampaign = truncate(campaign.Campaign, 100)
+}
+
+// applyReferrer 解析来源域名和渠道，发起统计请求的页面域名视为本站；
+// 带 utm_medium 时以推广参数声明的渠道为准
+func applyReferrer(c *gin.Context, record *database.VisitRecord) {
+	result := referrer.Classify(record.Referer, referrer.Host(c.GetHeader("Referer")), referrer.Host(c.GetHeader("Origin")))
+	record.ReferrerDomain = truncate(result.Domain, 100)
+	record.Channel = result.Channel
+	if channel := referrer.MediumChannel(record.UTMMedium); channel != "" {
+		record.Channel = channel
+	}
+	applySearch(record, result.Term)
+}
+
+// applySearch 记录搜索关键词，站内搜索页面的关键词优先于搜索引擎来源中的关键词
+func applySearch(record *database.VisitRecord, engineTerm string) {
+	record.SearchTerm, record.SearchType = "", ""
+	if term := referrer.SiteSearchTerm(record.Page); term != "" {
+		record.SearchTerm, record.SearchType = term, database.SearchTypeSite
+	} else if engineTerm != "" {
+		record.SearchTerm, record.SearchType = engineTerm, database.SearchTypeEngine
+	}
+}
+
+// truncate 按字符截断到 n 个字符以内，避免超出列长度
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// applyUserAgent 根据 User-Agent 在服务端解析设备、浏览器和操作系统
+// fill 模式只补全前端未传递的字段，always 模式始终以服务端解析结果为准
+func applyUserAgent(record *database.VisitRecord) {
+	mode := setting.UAParseMode
+	if mode == "off" || record.UserAgent == "" {
+		return
+	}
+	always := mode == "always"
+	ua := useragent.Parse(record.UserAgent)
+
+	if ua.Device != "" && (always || record.Device == "") {
+		record.Device = ua.Device
+	}
+	if ua.Browser != "" && (always || record.Browser == "" || strings.EqualFold(record.Browser, ua.Browser)) {
+		record.Browser = ua.Browser
+		record.BrowserVersion = ua.BrowserVersion
+	}
+	if ua.OS != "" && (always || record.OS == "" || strings.EqualFold(record.OS, ua.OS)) {
+		record.OS = ua.OS
+		record.OSVersion = ua.OSVersion
+	}
+}
+
+// applyBotDetection 在服务端判定机器人流量，忽略前端传入的 is_bot/bot_name
+func applyBotDetection(c *gin.Context, record *database.VisitRecord) {
+	result := botdetect.Detect(botdetect.Signals{
+		UserAgent:      record.UserAgent,
+		Parsed:         useragent.Parse(record.UserAgent),
+		IP:             record.IP,
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		Webdriver:      record.Webdriver,
+	})
+	record.IsBot = result.IsBot
+	record.BotName = result.Name
+	if result.IsBot && record.Device == "" {
+		record.Device = useragent.DeviceBot
+	}
+}
+
+// applyGeoIP 根据 IP 在服务端补全前端未传递的国家和城市
+func applyGeoIP(record *database.VisitRecord) {
+	if record.Country != "" && record.City != "" {
+		return
+	}
+	loc, ok := geoip.Lookup(record.IP)
+	if !ok {
+		return
+	}
+	if record.Country == "" {
+		record.Country = loc.Country
+	}
+	if record.City == "" {
+		record.City = loc.City
+	}
+}
+
+// includeBots 解析 include_bots 查询参数，默认排除机器人流量
+func includeBots(c *gin.Context) bool {
+	v, err := strconv.ParseBool(c.DefaultQuery("include_bots", "false"))
+	return err == nil && v
+}
+
+// requestLocation 解析 tz 参数（IANA 时区名称），未指定时使用报表时区
+func requestLocation(c *gin.Context) (*time.Location, error) {
+	return database.LoadLocation(c.Query("tz"))
+}
+
 // GetUserBehavior 获取用户行为分析
 // @Summary 获取用户行为分析
 // @Description 获取设备、浏览器、操作系统、地理位置等用户行为统计
 // @Tags 统计
 // @Accept json
 // @Produce json
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
 // @Success 200 {object} map[string]interface{} "成功"
 // @Router /stats/behavior [get]
 func GetUserBehavior(c *gin.Context) {
-	behavior := database.GetUserBehaviorStats()
+	behavior := database.GetUserBehaviorStats(includeBots(c))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": e.SUCCESS,
@@ -146,6 +300,7 @@ func GetUserBehavior(c *gin.Context) {
 // @Param start_date query string false "开始日期(YYYY-MM-DD)"
 // @Param end_date query string false "结束日期(YYYY-MM-DD)"
 // @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
 // @Success 200 {object} map[string]interface{} "成功"
 // @Router /stats/pages [get]
 func GetTopPages(c *gin.Context) {
@@ -159,7 +314,7 @@ func GetTopPages(c *gin.Context) {
 	end := c.Query("end_date")
 	language := c.Query("language")
 
-	stats, err := database.GetTopPages(limit, start, end, language)
+	stats, err := database.GetTopPages(limit, start, end, language, includeBots(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get pages", "data": gin.H{}})
 		return
@@ -167,58 +322,30 @@ func GetTopPages(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"pages": stats}})
 }
 
-// GetTrend 获取访问趋势
-// @Summary 获取访问趋势
-// @Description 返回最近N天的访问量、独立访客、独立会话趋势
+// GetBotStats 获取机器人流量报告
+// @Summary 获取机器人流量报告
+// @Description 返回最近N天的机器人访问量、占比、按爬虫名称统计、机器人访问最多的页面及每日趋势
 // @Tags 统计
 // @Accept json
 // @Produce json
 // @Param days query int false "天数" default(30)
-// @Param language query string false "语言过滤"
 // @Success 200 {object} map[string]interface{} "成功"
-// @Router /stats/trend [get]
-func GetTrend(c *gin.Context) {
+// @Router /stats/bots [get]
+func GetBotStats(c *gin.Context) {
 	days := 30
 	if v := c.Query("days"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			days = n
 		}
 	}
-	language := c.Query("language")
-	res, err := database.GetTrend(days, language)
+	res, err := database.GetBotStats(days)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get trend", "data": gin.H{}})
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get bot stats", "data": gin.H{}})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
 }
 
-// GetDaily 获取日统计（与趋势同结构，主要用于固定时间窗口或分页）
-// @Summary 获取日统计
-// @Description 返回按天聚合的访问/访客/会话数据
-// @Tags 统计
-// @Accept json
-// @Produce json
-// @Param days query int false "天数" default(30)
-// @Param language query string false "语言过滤"
-// @Success 200 {object} map[string]interface{} "成功"
-// @Router /stats/daily [get]
-func GetDaily(c *gin.Context) {
-	days := 30
-	if v := c.Query("days"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			days = n
-		}
-	}
-	language := c.Query("language")
-	res, err := database.GetTrend(days, language)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get daily", "data": gin.H{}})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"points": res.Points}})
-}
-
 // GetContentStats 获取内容统计
 // @Summary 获取内容统计
 // @Description 返回文章/标签/分类等汇总
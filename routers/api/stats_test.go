@@ -0,0 +1,58 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/referrer"
+	"gorm.io/gorm/logger"
+)
+
+func TestRecordVisitReferrer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DATABASE_URL", ":memory:")
+	if err := database.InitDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	database.DB.Logger = logger.Discard
+
+	r := gin.New()
+	r.POST("/stats/visit", RecordVisit)
+
+	tests := []struct {
+		name    string
+		body    string
+		channel string
+		domain  string
+	}{
+		// 请求头 Referer 是统计脚本所在的页面，不作为来源
+		{"no referrer is direct", `{"page":"/posts/a/","session_id":"s1"}`, referrer.ChannelDirect, ""},
+		{"empty referrer is direct", `{"page":"/posts/b/","session_id":"s1","referer":""}`, referrer.ChannelDirect, ""},
+		{"internal navigation", `{"page":"/posts/c/","session_id":"s1","referer":"https://blog.example.com/"}`, referrer.ChannelInternal, "blog.example.com"},
+		{"external referrer", `{"page":"/posts/d/","session_id":"s1","referer":"https://someblog.net/x"}`, referrer.ChannelReferral, "someblog.net"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/stats/visit", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Referer", "https://blog.example.com/posts/a/")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			var record database.VisitRecord
+			if err := database.DB.Order("id DESC").First(&record).Error; err != nil {
+				t.Fatal(err)
+			}
+			if record.Channel != tt.channel || record.ReferrerDomain != tt.domain {
+				t.Errorf("channel = %q, domain = %q, want %q, %q", record.Channel, record.ReferrerDomain, tt.channel, tt.domain)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+	"gorm.io/gorm"
+)
+
+type toolPayload struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Category    *string `json:"category"`
+	Icon        *string `json:"icon"`
+	URL         *string `json:"url"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// GetTools 获取工具列表
+// @Summary 获取工具列表
+// @Description 返回启用中的工具，支持按分类过滤
+// @Tags 工具
+// @Produce json
+// @Param category query string false "分类"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools [get]
+func GetTools(c *gin.Context) {
+	tools, err := database.GetTools(c.Query("category"), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get tools", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"tools": tools}})
+}
+
+// GetTool 获取单个工具
+// @Summary 获取工具
+// @Tags 工具
+// @Produce json
+// @Param id path int true "工具ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools/{id} [get]
+func GetTool(c *gin.Context) {
+	id, ok := parseToolID(c)
+	if !ok {
+		return
+	}
+	tool, err := database.GetTool(id)
+	if err != nil {
+		respondToolError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": tool})
+}
+
+// AddTool 新增工具
+// @Summary 新增工具
+// @Tags 工具
+// @Accept json
+// @Produce json
+// @Param body body toolPayload true "工具信息"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools [post]
+func AddTool(c *gin.Context) {
+	var payload toolPayload
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Name == nil || *payload.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "name is required", "data": gin.H{}})
+		return
+	}
+
+	tool := database.Tool{Name: *payload.Name, IsActive: true}
+	if payload.Description != nil {
+		tool.Description = *payload.Description
+	}
+	if payload.Category != nil {
+		tool.Category = *payload.Category
+	}
+	if payload.Icon != nil {
+		tool.Icon = *payload.Icon
+	}
+	if payload.URL != nil {
+		tool.URL = *payload.URL
+	}
+	if payload.IsActive != nil {
+		tool.IsActive = *payload.IsActive
+	}
+
+	if err := database.AddTool(&tool); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to add tool", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": tool})
+}
+
+// EditTool 更新工具
+// @Summary 更新工具
+// @Description 未传递的字段保持不变
+// @Tags 工具
+// @Accept json
+// @Produce json
+// @Param id path int true "工具ID"
+// @Param body body toolPayload true "需要修改的字段"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools/{id} [put]
+func EditTool(c *gin.Context) {
+	id, ok := parseToolID(c)
+	if !ok {
+		return
+	}
+	var payload toolPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid JSON data", "data": gin.H{}})
+		return
+	}
+
+	data := make(map[string]interface{})
+	if payload.Name != nil {
+		data["name"] = *payload.Name
+	}
+	if payload.Description != nil {
+		data["description"] = *payload.Description
+	}
+	if payload.Category != nil {
+		data["category"] = *payload.Category
+	}
+	if payload.Icon != nil {
+		data["icon"] = *payload.Icon
+	}
+	if payload.URL != nil {
+		data["url"] = *payload.URL
+	}
+	if payload.IsActive != nil {
+		data["is_active"] = *payload.IsActive
+	}
+
+	tool, err := database.EditTool(id, data)
+	if err != nil {
+		respondToolError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": tool})
+}
+
+// DeleteTool 删除工具
+// @Summary 删除工具
+// @Description 软删除工具，已有的使用记录保留
+// @Tags 工具
+// @Produce json
+// @Param id path int true "工具ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools/{id} [delete]
+func DeleteTool(c *gin.Context) {
+	id, ok := parseToolID(c)
+	if !ok {
+		return
+	}
+	if err := database.DeleteTool(id); err != nil {
+		respondToolError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"deleted": true}})
+}
+
+// RecordToolUsage 记录工具使用
+// @Summary 记录工具使用
+// @Description 记录一次工具使用，服务端采集 IP 和 User-Agent
+// @Tags 工具
+// @Produce json
+// @Param id path int true "工具ID"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools/{id}/usage [post]
+func RecordToolUsage(c *gin.Context) {
+	id, ok := parseToolID(c)
+	if !ok {
+		return
+	}
+	tool, err := database.GetTool(id)
+	if err != nil {
+		respondToolError(c, err)
+		return
+	}
+	if !tool.IsActive {
+		c.JSON(http.StatusNotFound, gin.H{"code": e.ERROR_NOT_EXIST_TOOL, "msg": e.GetMsg(e.ERROR_NOT_EXIST_TOOL), "data": gin.H{}})
+		return
+	}
+
+	usage := database.ToolUsage{
+		ToolID:    tool.ID,
+		IP:        requestIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+	if err := database.AddToolUsage(&usage); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to record tool usage", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"recorded": true}})
+}
+
+// GetToolTrend 获取工具使用趋势
+// @Summary 获取工具使用趋势
+// @Description 返回单个工具最近N天的使用次数和独立用户数
+// @Tags 工具
+// @Produce json
+// @Param id path int true "工具ID"
+// @Param days query int false "天数" default(30)
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /tools/{id}/trend [get]
+func GetToolTrend(c *gin.Context) {
+	id, ok := parseToolID(c)
+	if !ok {
+		return
+	}
+	if _, err := database.GetTool(id); err != nil {
+		respondToolError(c, err)
+		return
+	}
+	days := 30
+	if v := c.Query("days"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			days = n
+		}
+	}
+	res, err := database.GetToolTrend(id, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get tool trend", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// GetTopTools 获取工具使用排行
+// @Summary 获取工具使用排行
+// @Description 按使用次数降序返回工具列表
+// @Tags 统计
+// @Produce json
+// @Param limit query int false "返回数量" default(10)
+// @Param start_date query string false "开始日期(YYYY-MM-DD)"
+// @Param end_date query string false "结束日期(YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "成功"
+// @Router /stats/tools [get]
+func GetTopTools(c *gin.Context) {
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	stats, err := database.GetTopTools(limit, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to get tools", "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": gin.H{"tools": stats}})
+}
+
+func parseToolID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": e.GetMsg(e.INVALID_PARAMS), "data": gin.H{}})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondToolError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": e.ERROR_NOT_EXIST_TOOL, "msg": e.GetMsg(e.ERROR_NOT_EXIST_TOOL), "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": "Failed to access tool", "data": gin.H{}})
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/e"
+)
+
+// GetTrend 获取访问趋势
+// @Summary 获取访问趋势
+// @Description 按小时、天、周（周一开始）或月返回访问量、独立访客、独立会话，没有数据的时间段补 0。指定 start 时查询 [start, end]，否则查询最近N天；compare=true 时同时返回上一个等长周期及差值、变化百分比。周和月的独立访客/会话为每天去重结果之和；按小时统计读取原始访问记录，只覆盖保留期内的数据
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param start query string false "开始时间(YYYY-MM-DD、YYYY-MM-DD HH:MM:SS 或 RFC3339)"
+// @Param end query string false "结束时间，只有日期时包含当天，默认为现在"
+// @Param days query int false "未指定 start 时的天数" default(30)
+// @Param granularity query string false "时间粒度：hour、day、week、month" default(day)
+// @Param compare query bool false "是否与上一周期对比" default(false)
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "划分时间段的时区（如 Asia/Shanghai），默认为报表时区；其他时区读取原始访问记录"
+// @Success 200 {object} response.TrendResult "成功"
+// @Router /stats/trend [get]
+func GetTrend(c *gin.Context) {
+	trend(c, c.DefaultQuery("granularity", database.GranularityDay), "Failed to get trend")
+}
+
+// GetDaily 获取日统计（与趋势同结构，固定按天聚合）
+// @Summary 获取日统计
+// @Description 返回按天聚合的访问/访客/会话数据，时间范围和对比参数与 /stats/trend 相同
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param start query string false "开始时间(YYYY-MM-DD、YYYY-MM-DD HH:MM:SS 或 RFC3339)"
+// @Param end query string false "结束时间，只有日期时包含当天，默认为现在"
+// @Param days query int false "未指定 start 时的天数" default(30)
+// @Param compare query bool false "是否与上一周期对比" default(false)
+// @Param language query string false "语言过滤"
+// @Param include_bots query bool false "是否包含机器人流量" default(false)
+// @Param tz query string false "划分日期的时区（如 Asia/Shanghai），默认为报表时区"
+// @Success 200 {object} response.TrendResult "成功"
+// @Router /stats/daily [get]
+func GetDaily(c *gin.Context) {
+	trend(c, database.GranularityDay, "Failed to get daily")
+}
+
+// trend 解析时间范围和对比参数后查询趋势
+func trend(c *gin.Context, granularity, failMsg string) {
+	loc, err := requestLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": "Invalid tz", "data": gin.H{}})
+		return
+	}
+	start, end, err := trendRange(c, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	compare, _ := strconv.ParseBool(c.DefaultQuery("compare", "false"))
+	language := c.Query("language")
+
+	res, err := database.GetTrend(start, end, granularity, compare, language, includeBots(c))
+	if errors.Is(err, database.ErrInvalidGranularity) || errors.Is(err, database.ErrInvalidRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": e.INVALID_PARAMS, "msg": err.Error(), "data": gin.H{}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": e.ERROR, "msg": failMsg, "data": gin.H{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": e.SUCCESS, "msg": e.GetMsg(e.SUCCESS), "data": res})
+}
+
+// trendRange 解析 loc 时区中趋势的时间范围：指定 start 时为 [start, end]（end 默认为现在），否则为最近 days 天（含今天）
+func trendRange(c *gin.Context, loc *time.Location) (time.Time, time.Time, error) {
+	end := time.Now().In(loc)
+	if v := c.Query("end"); v != "" {
+		t, dateOnly, err := parseTrendTime(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid end")
+		}
+		end = t
+		if dateOnly {
+			end = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+	}
+
+	if v := c.Query("start"); v != "" {
+		start, _, err := parseTrendTime(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid start")
+		}
+		return start, end, nil
+	}
+
+	days := 30
+	if v := c.Query("days"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 365 {
+			days = n
+		}
+	}
+	y, m, d := end.Date()
+	return time.Date(y, m, d-days+1, 0, 0, 0, 0, end.Location()), end, nil
+}
+
+// parseTrendTime 解析 YYYY-MM-DD、YYYY-MM-DD HH:MM:SS 或 RFC3339 格式的时间，dateOnly 表示只有日期
+func parseTrendTime(v string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.ParseInLocation("2006-01-02", v, loc); err == nil {
+		return t, true, nil
+	}
+	if t, err = time.ParseInLocation("2006-01-02 15:04:05", v, loc); err == nil {
+		return t, false, nil
+	}
+	if t, err = time.Parse(time.RFC3339, v); err == nil {
+		return t.In(loc), false, nil
+	}
+	return time.Time{}, false, err
+}
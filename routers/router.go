@@ -9,8 +9,16 @@ import (
 
 	ginswagger "github.com/swaggo/gin-swagger"
 	swaggerFiles "github.com/swaggo/gin-swagger/swaggerFiles"
+	"github.com/webbleen/go-gin/middleware/apikey"
+	"github.com/webbleen/go-gin/middleware/jwt"
 	"github.com/webbleen/go-gin/models/database"
+	"github.com/webbleen/go-gin/pkg/botdetect"
+	"github.com/webbleen/go-gin/pkg/geoip"
+	"github.com/webbleen/go-gin/pkg/iputil"
+	"github.com/webbleen/go-gin/pkg/privacy"
+	"github.com/webbleen/go-gin/pkg/referrer"
 	"github.com/webbleen/go-gin/pkg/setting"
+	"github.com/webbleen/go-gin/pkg/useragent"
 	"github.com/webbleen/go-gin/routers/api"
 )
 
@@ -22,6 +30,74 @@ func InitRouter() *gin.Engine {
 		log.Printf("数据库初始化失败: %v", err)
 	}
 
+	// 报表时区：决定“今天”、按天去重和 daily_stats 的日期，配置无效时使用服务器本地时区
+	if err := database.SetReportTimeZone(setting.ReportTimeZone); err != nil {
+		log.Printf("报表时区 %q 无效，使用服务器本地时区: %v", setting.ReportTimeZone, err)
+	}
+
+	// 访问记录异步批量写入
+	api.StartIngest()
+
+	// 原始访问记录超过保留期后汇总并删除/归档
+	database.StartRetention(setting.RetentionDays, setting.RetentionInterval, setting.RetentionAction)
+
+	// 加载自定义 User-Agent 规则，失败时继续使用内置规则
+	if setting.UARulesFile != "" {
+		if err := useragent.LoadRulesFile(setting.UARulesFile); err != nil {
+			log.Printf("加载 User-Agent 规则失败: %v", err)
+		}
+	}
+
+	// 加载自定义爬虫 IP 段，失败时继续使用内置列表
+	if setting.BotIPRangesFile != "" {
+		if err := botdetect.LoadRangesFile(setting.BotIPRangesFile); err != nil {
+			log.Printf("加载爬虫 IP 段失败: %v", err)
+		}
+	}
+	botdetect.SetRequireAcceptLanguage(setting.BotRequireAcceptLanguage)
+
+	// 来源解析：本站域名与自定义来源域名列表，加载失败时继续使用内置列表
+	referrer.SetSiteHosts(setting.SiteHosts)
+	referrer.SetSiteSearchParams(setting.SiteSearchParams)
+	if setting.ReferrerSourcesFile != "" {
+		if err := referrer.LoadSourcesFile(setting.ReferrerSourcesFile); err != nil {
+			log.Printf("加载来源域名列表失败: %v", err)
+		}
+	}
+
+	// 客户端 IP 解析：可信代理与请求头优先级
+	if resolver, err := iputil.NewResolver(setting.TrustedProxies, setting.ClientIPHeaders); err != nil {
+		log.Printf("可信代理配置无效，不读取客户端 IP 请求头: %v", err)
+	} else {
+		iputil.SetResolver(resolver)
+	}
+
+	// egress 模式下维护服务器出口 IP 缓存，请求路径上不再调用外部服务
+	if setting.PrivateIPMode == "egress" {
+		if setting.EgressIP != "" {
+			iputil.SetEgressIP(setting.EgressIP)
+		} else {
+			iputil.StartEgressRefresher(setting.EgressIPURLs, setting.EgressIPRefresh)
+		}
+	}
+
+	// 访问记录 IP 存储模式，配置无效时保存完整地址
+	if generated, err := privacy.Configure(setting.IPPrivacyMode, setting.IPHashKey); err != nil {
+		log.Printf("IP 存储模式配置无效，保存完整地址: %v", err)
+	} else if generated {
+		log.Printf("未配置 IP_HASH_KEY，已随机生成，重启后同一天的访客将无法去重")
+	}
+
+	// 加载本地 GeoIP 数据库，文件更新后自动重新加载
+	geoip.SetLocale(setting.GeoIPLocale)
+	geoip.SetHTTPFallback(setting.GeoIPHTTPFallback)
+	if setting.GeoIPDBPath != "" {
+		if err := geoip.Open(setting.GeoIPDBPath); err != nil {
+			log.Printf("加载 GeoIP 数据库失败: %v", err)
+		}
+		geoip.Watch(setting.GeoIPReloadInterval)
+	}
+
 	r := gin.New()
 
 	// 配置模板引擎
@@ -56,11 +132,16 @@ func InitRouter() *gin.Engine {
 	r.GET("/healthz", api.Healthz)
 	r.GET("/readyz", api.Readyz)
 
+	// 签发访问令牌
+	r.POST("/auth", api.GetAuth)
+
 	// 统计相关API - 不需要认证
 	stats := r.Group("/stats")
 	{
 		// 记录访问
 		stats.POST("/visit", api.RecordVisit)
+		// 批量记录访问（navigator.sendBeacon）
+		stats.POST("/visits/batch", api.RecordBatch)
 		// 获取访问统计
 		stats.GET("/visits", api.GetVisitStats)
 		// 获取用户行为分析
@@ -70,14 +151,81 @@ func InitRouter() *gin.Engine {
 		// 获取访问趋势 & 日统计
 		stats.GET("/trend", api.GetTrend)
 		stats.GET("/daily", api.GetDaily)
-		// 内容统计读写
+		// 机器人流量报告
+		stats.GET("/bots", api.GetBotStats)
+		// 自定义事件
+		stats.POST("/event", api.RecordEvent)
+		stats.GET("/events", api.GetTopEvents)
+		stats.GET("/events/:name", api.GetEventStats)
+		// 页面停留时长和滚动深度
+		stats.POST("/engagement", api.RecordEngagement)
+		stats.GET("/engagement", api.GetPageEngagement)
+		// 会话分析
+		stats.GET("/sessions", api.GetSessionStats)
+		stats.GET("/sessions/transitions", api.GetSessionTransitions)
+		// 漏斗与路径分析
+		stats.GET("/funnel", api.GetFunnel)
+		stats.GET("/paths", api.GetPaths)
+		// 来源与推广活动
+		stats.GET("/referrers", api.GetReferrers)
+		stats.GET("/campaigns", api.GetCampaigns)
+		// 搜索关键词
+		stats.GET("/search", api.GetSearchTerms)
+		stats.GET("/search/zero-results", api.GetZeroResultSearches)
+		stats.GET("/search/trend", api.GetSearchTrend)
+		// 实时访客：SSE 推送和 JSON 快照
+		stats.GET("/live", api.StreamLive)
+		stats.GET("/live/snapshot", api.GetLive)
+		// 内容统计读
 		stats.GET("/content", api.GetContentStats)
-		stats.POST("/content", api.UpdateContentStats)
+		// 工具使用排行
+		stats.GET("/tools", api.GetTopTools)
+	}
+
+	// 统计相关API - 需要认证（内容写入、原始访问记录、导出）
+	// 支持管理员 JWT 或具备对应权限范围的 API Key（X-API-Key）
+	statsAuth := r.Group("/stats")
+	{
+		// 内容统计写
+		statsAuth.POST("/content", apikey.RequireScope(database.ScopeStatsWrite), api.UpdateContentStats)
 		// Dashboard API
-		stats.GET("/records", api.GetVisitRecords)
-		stats.GET("/overview", api.GetVisitOverview)
+		statsAuth.GET("/records", apikey.RequireScope(database.ScopeStatsRead), api.GetVisitRecords)
+		statsAuth.GET("/overview", apikey.RequireScope(database.ScopeStatsRead), api.GetVisitOverview)
 		// 导出 CSV
-		stats.GET("/export", api.ExportVisitRecords)
+		statsAuth.GET("/export", apikey.RequireScope(database.ScopeExport), api.ExportVisitRecords)
+	}
+
+	// API Key 管理 - 仅管理员
+	keys := r.Group("/keys")
+	keys.Use(jwt.JWT())
+	{
+		keys.GET("", api.GetAPIKeys)
+		keys.POST("", api.AddAPIKey)
+		keys.GET("/:id", api.GetAPIKey)
+		keys.PUT("/:id", api.EditAPIKey)
+		keys.DELETE("/:id", api.DeleteAPIKey)
+	}
+
+	// 工具目录与使用统计 - 读取和记录使用不需要认证
+	tools := r.Group("/tools")
+	{
+		tools.GET("", api.GetTools)
+		tools.GET("/:id", api.GetTool)
+		tools.POST("/:id/usage", api.RecordToolUsage)
+		tools.GET("/:id/trend", api.GetToolTrend)
+		// 工具管理 - 仅管理员
+		tools.POST("", jwt.JWT(), api.AddTool)
+		tools.PUT("/:id", jwt.JWT(), api.EditTool)
+		tools.DELETE("/:id", jwt.JWT(), api.DeleteTool)
+	}
+
+	// 聊天会话 - 不需要认证
+	chatGroup := r.Group("/chat")
+	{
+		chatGroup.POST("/sessions", api.CreateChatSession)
+		chatGroup.GET("/sessions/:session_id/messages", api.GetChatMessages)
+		chatGroup.POST("/sessions/:session_id/messages", api.AddChatMessage)
+		chatGroup.DELETE("/sessions/:session_id", api.DeleteChatSession)
 	}
 
 	// 代理服务API - 不需要认证
@@ -93,8 +241,8 @@ func InitRouter() *gin.Engine {
 		proxy.GET("/ip", api.GetClientIP)
 	}
 
-	// Dashboard 页面
-	r.GET("/dashboard", api.DashboardPage)
+	// Dashboard 页面 - 需要认证（可通过 ?token= 传递令牌）
+	r.GET("/dashboard", jwt.PageJWT(), api.DashboardPage)
 
 	return r
 }
@@ -30,6 +30,20 @@ echo "4. 测试代理 API..."
 curl -s "$BASE_URL/proxy/ip" | jq '.' || echo "代理 API 失败"
 echo ""
 
+# 测试聊天 API
+echo "5. 测试聊天 API..."
+SESSION_ID=$(curl -s -X POST "$BASE_URL/chat/sessions" | jq -r '.data.session_id')
+if [ -n "$SESSION_ID" ] && [ "$SESSION_ID" != "null" ]; then
+    curl -s -X POST "$BASE_URL/chat/sessions/$SESSION_ID/messages" \
+        -H "Content-Type: application/json" \
+        -d '{"content": "你好"}' | jq '.' || echo "发送消息失败"
+    curl -s "$BASE_URL/chat/sessions/$SESSION_ID/messages" | jq '.' || echo "获取历史失败"
+    curl -s -X DELETE "$BASE_URL/chat/sessions/$SESSION_ID" | jq '.' || echo "删除会话失败"
+else
+    echo "创建聊天会话失败"
+fi
+echo ""
+
 echo "================================"
 echo "✅ 测试完成！"
 echo ""